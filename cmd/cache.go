@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nmsde/docktor/internal/config"
+	"github.com/nmsde/docktor/internal/gcp"
+	"github.com/nmsde/docktor/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneMaxAgeDays int
+	pruneMaxBytes   int64
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage docktor's local and remote caches",
+}
+
+var cacheWarmCmd = &cobra.Command{
+	Use:   "warm",
+	Short: "Download the trivy vulnerability DB into the cache directory ahead of time",
+	Long: `Download the trivy vulnerability DB into --cache-dir (or the configured
+cache_dir) so a later "docktor scan --offline-scan" can run without network
+access. Safe to run repeatedly - trivy no-ops once its cached DB is fresh.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		trivyScanner := scanner.NewTrivyScanner(scanner.NewBinaryRunner(), scanner.SilentReporter{})
+		if err := trivyScanner.WarmCache(cmd.Context(), cfg.CacheDir); err != nil {
+			return err
+		}
+
+		fmt.Printf("✅ Trivy vulnerability DB cached at %s\n", cfg.CacheDir)
+		return nil
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Garbage-collect the gcp backend's content-addressed build context cache",
+	Long: `Delete content-addressed build contexts and cached scan results (the
+sha256/ objects createAndUploadContext and cacheScanResults write to the
+gcp backend's build bucket) that are older than --max-age-days, then, if
+the cache is still over --max-bytes, the least-recently-used remaining
+objects until it's back under budget. gcp backend only.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		if cfg.Backend != "gcp" {
+			return fmt.Errorf("cache prune only supports the gcp backend, got %q", cfg.Backend)
+		}
+
+		client, err := gcp.NewClient(cfg.GCPProjectID, cfg.GCPServiceAccount, cfg.GCPServiceKeyPath)
+		if err != nil {
+			return fmt.Errorf("failed to create GCP client: %w", err)
+		}
+
+		if err := client.PruneContextCache(cmd.Context(), pruneMaxAgeDays, pruneMaxBytes); err != nil {
+			return fmt.Errorf("failed to prune context cache: %w", err)
+		}
+
+		fmt.Println("✅ Context cache pruned")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheWarmCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+
+	cachePruneCmd.Flags().IntVar(&pruneMaxAgeDays, "max-age-days", 30, "Delete cached build contexts and scan results older than this many days; 0 disables age-based pruning")
+	cachePruneCmd.Flags().Int64Var(&pruneMaxBytes, "max-bytes", 0, "Delete least-recently-used cached objects until the cache is under this size; 0 disables size-based pruning")
+}
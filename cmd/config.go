@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/nmsde/docktor/internal/config"
+	"github.com/nmsde/docktor/internal/fly"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and manage .docktor.yaml profiles",
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the current profile and its settings",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pf, err := config.LoadProfileFile()
+		if err != nil {
+			return fmt.Errorf("failed to load profiles: %w", err)
+		}
+
+		names := make([]string, 0, len(pf.Profiles))
+		for name := range pf.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fmt.Printf("Current profile: %s\n\n", pf.CurrentProfile)
+		for _, name := range names {
+			cfg := pf.Profiles[name]
+			marker := "  "
+			if name == pf.CurrentProfile {
+				marker = "* "
+			}
+			fmt.Printf("%s%s (backend: %s)\n", marker, name, cfg.Backend)
+		}
+
+		cfg, err := pf.Current()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println()
+		switch cfg.Backend {
+		case "gcp":
+			fmt.Printf("  gcp_project_id: %s\n", cfg.GCPProjectID)
+			fmt.Printf("  gcp_region: %s\n", cfg.GCPRegion)
+			fmt.Printf("  gcp_service_account: %s\n", cfg.GCPServiceAccount)
+		case "fly":
+			fmt.Printf("  fly_org_id: %s\n", cfg.FlyOrgID)
+			fmt.Printf("  fly_region: %s\n", cfg.FlyRegion)
+			fmt.Printf("  fly_api_token: %s\n", redactToken(cfg.FlyAPIToken))
+		}
+
+		return nil
+	},
+}
+
+var configUseCmd = &cobra.Command{
+	Use:   "use <profile>",
+	Short: "Switch the current profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pf, err := config.LoadProfileFile()
+		if err != nil {
+			return fmt.Errorf("failed to load profiles: %w", err)
+		}
+
+		if err := pf.Use(args[0]); err != nil {
+			return err
+		}
+
+		if err := pf.Save(); err != nil {
+			return fmt.Errorf("failed to save profiles: %w", err)
+		}
+
+		fmt.Printf("✅ Switched to profile %q\n", args[0])
+		return nil
+	},
+}
+
+var configTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Exercise the current profile's credentials end-to-end",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		switch cfg.Backend {
+		case "gcp":
+			if cfg.GCPServiceKeyPath == "" {
+				fmt.Println("No service account key file configured; relying on GOOGLE_APPLICATION_CREDENTIALS. Skipping key verification.")
+				return nil
+			}
+			if err := verifyGCPServiceAccount(cfg.GCPServiceKeyPath); err != nil {
+				return fmt.Errorf("GCP credential check failed: %w", err)
+			}
+			fmt.Println("✅ GCP service account credentials are valid.")
+		case "fly":
+			client, err := fly.NewClient(cfg.FlyAPIToken)
+			if err != nil {
+				return fmt.Errorf("failed to create Fly.io client: %w", err)
+			}
+			if err := client.Ping(cmd.Context()); err != nil {
+				return fmt.Errorf("Fly.io credential check failed: %w", err)
+			}
+			fmt.Println("✅ Fly.io API token is valid.")
+		case "local":
+			fmt.Println("Local backend requires no credentials.")
+		}
+
+		return nil
+	},
+}
+
+// redactToken shows only enough of a secret to recognize it, never the
+// whole value.
+func redactToken(token string) string {
+	if token == "" {
+		return ""
+	}
+	if len(token) <= 4 {
+		return "****"
+	}
+	return token[:4] + "****"
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configUseCmd)
+	configCmd.AddCommand(configTestCmd)
+}
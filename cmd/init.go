@@ -1,91 +1,167 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"regexp"
 
+	survey "github.com/AlecAivazis/survey/v2"
+	"github.com/nmsde/docktor/internal/config"
 	"github.com/spf13/cobra"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/cloudbuild/v1"
 )
 
+var gcpProjectIDPattern = regexp.MustCompile(`^[a-z][-a-z0-9]{4,28}[a-z0-9]$`)
+
+var initProfile string
+
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize Docktor configuration",
-	Long: `Initialize Docktor configuration by creating a .docktor.env file in the current directory.
-This file will contain your Google Cloud configuration and service account details.`,
+	Long: `Initialize Docktor configuration by interactively collecting your backend
+credentials and writing them to a named profile in .docktor.yaml. Re-running
+init with --profile adds or replaces a separate profile without touching
+the others.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// Check if .docktor.env already exists
-		if _, err := os.Stat(".docktor.env"); err == nil {
-			return fmt.Errorf(".docktor.env already exists")
-		}
-
-		// Get Google Cloud project ID
-		fmt.Print("Enter your Google Cloud project ID: ")
-		var gcpProjectID string
-		fmt.Scanln(&gcpProjectID)
-		if gcpProjectID == "" {
-			return fmt.Errorf("Google Cloud project ID is required")
+		profileName := initProfile
+		if profileName == "" {
+			profileName = config.DefaultProfileName
 		}
 
-		// Get Google Cloud region
-		fmt.Print("Enter your Google Cloud region (default: global): ")
-		var gcpRegion string
-		fmt.Scanln(&gcpRegion)
-		if gcpRegion == "" {
-			gcpRegion = "global"
+		var backend string
+		if err := survey.AskOne(&survey.Select{
+			Message: "Which builder backend do you want to configure?",
+			Options: []string{"gcp", "fly", "local"},
+			Default: "gcp",
+		}, &backend); err != nil {
+			return fmt.Errorf("prompt cancelled: %w", err)
 		}
 
-		// Ask about service account configuration
-		fmt.Print("Do you want to use a service account key file? (y/n): ")
-		var useServiceAccount string
-		fmt.Scanln(&useServiceAccount)
-
-		var gcpServiceAccount, gcpServiceKeyPath string
-		if useServiceAccount == "y" || useServiceAccount == "Y" {
-			// Get service account email
-			fmt.Print("Enter your Google Cloud service account email: ")
-			fmt.Scanln(&gcpServiceAccount)
-			if gcpServiceAccount == "" {
-				return fmt.Errorf("service account email is required")
-			}
+		cfg := &config.Config{Backend: backend}
 
-			// Get service account key file path
-			fmt.Print("Enter the path to your service account key file: ")
-			fmt.Scanln(&gcpServiceKeyPath)
-			if gcpServiceKeyPath == "" {
-				return fmt.Errorf("service account key file path is required")
+		switch backend {
+		case "gcp":
+			if err := promptGCP(cfg); err != nil {
+				return err
 			}
-
-			// Verify the key file exists
-			if _, err := os.Stat(gcpServiceKeyPath); os.IsNotExist(err) {
-				return fmt.Errorf("service account key file not found at: %s", gcpServiceKeyPath)
+		case "fly":
+			if err := promptFly(cfg); err != nil {
+				return err
 			}
+		case "local":
+			// No credentials required.
 		}
 
-		// Create .docktor.env file
-		envContent := fmt.Sprintf(`GCP_PROJECT_ID=%s
-GCP_REGION=%s
-`, gcpProjectID, gcpRegion)
-
-		// Add service account configuration if provided
-		if gcpServiceAccount != "" && gcpServiceKeyPath != "" {
-			envContent += fmt.Sprintf(`GCP_SERVICE_ACCOUNT=%s
-GCP_SERVICE_KEY_PATH=%s
-`, gcpServiceAccount, gcpServiceKeyPath)
+		pf, err := config.LoadProfileFile()
+		if err != nil {
+			return fmt.Errorf("failed to load existing profiles: %w", err)
 		}
 
-		if err := os.WriteFile(".docktor.env", []byte(envContent), 0600); err != nil {
-			return fmt.Errorf("failed to write .docktor.env: %w", err)
-		}
+		pf.Profiles[profileName] = cfg
+		pf.CurrentProfile = profileName
 
-		fmt.Println("\nConfiguration initialized successfully!")
-		if gcpServiceAccount == "" {
-			fmt.Println("\nNote: No service account configured. Make sure to set the GOOGLE_APPLICATION_CREDENTIALS environment variable before running docktor scan.")
+		if err := pf.Save(); err != nil {
+			return fmt.Errorf("failed to save profile: %w", err)
 		}
 
+		fmt.Printf("\n✅ Profile %q saved to .docktor.yaml and set as current.\n", profileName)
 		return nil
 	},
 }
 
+func promptGCP(cfg *config.Config) error {
+	if err := survey.AskOne(&survey.Input{Message: "Google Cloud project ID:"}, &cfg.GCPProjectID,
+		survey.WithValidator(func(ans interface{}) error {
+			s, _ := ans.(string)
+			if !gcpProjectIDPattern.MatchString(s) {
+				return fmt.Errorf("project ID must match %s", gcpProjectIDPattern.String())
+			}
+			return nil
+		}),
+	); err != nil {
+		return fmt.Errorf("prompt cancelled: %w", err)
+	}
+
+	if err := survey.AskOne(&survey.Input{Message: "Google Cloud region:", Default: "global"}, &cfg.GCPRegion); err != nil {
+		return fmt.Errorf("prompt cancelled: %w", err)
+	}
+
+	var useServiceAccount bool
+	if err := survey.AskOne(&survey.Confirm{Message: "Use a service account key file?", Default: true}, &useServiceAccount); err != nil {
+		return fmt.Errorf("prompt cancelled: %w", err)
+	}
+
+	if !useServiceAccount {
+		fmt.Println("Note: docktor will rely on GOOGLE_APPLICATION_CREDENTIALS at runtime.")
+		return nil
+	}
+
+	if err := survey.AskOne(&survey.Input{Message: "Service account email:"}, &cfg.GCPServiceAccount,
+		survey.WithValidator(survey.Required),
+	); err != nil {
+		return fmt.Errorf("prompt cancelled: %w", err)
+	}
+
+	if err := survey.AskOne(&survey.Input{Message: "Path to service account key file:"}, &cfg.GCPServiceKeyPath,
+		survey.WithValidator(survey.Required),
+	); err != nil {
+		return fmt.Errorf("prompt cancelled: %w", err)
+	}
+
+	return verifyGCPServiceAccount(cfg.GCPServiceKeyPath)
+}
+
+// verifyGCPServiceAccount parses the key file and exchanges it for an
+// OAuth2 token, so init fails fast on a malformed or revoked key rather
+// than leaving the user to discover it on their first `docktor scan`.
+func verifyGCPServiceAccount(keyPath string) error {
+	keyFile, err := os.ReadFile(keyPath)
+	if err != nil {
+		return fmt.Errorf("service account key file not found at %s: %w", keyPath, err)
+	}
+
+	var keyData map[string]interface{}
+	if err := json.Unmarshal(keyFile, &keyData); err != nil {
+		return fmt.Errorf("invalid service account key file: %w", err)
+	}
+
+	jwtConfig, err := google.JWTConfigFromJSON(keyFile, cloudbuild.CloudPlatformScope)
+	if err != nil {
+		return fmt.Errorf("failed to parse service account credentials: %w", err)
+	}
+
+	if _, err := jwtConfig.TokenSource(context.Background()).Token(); err != nil {
+		return fmt.Errorf("failed to exchange service account credentials for a token: %w", err)
+	}
+
+	return nil
+}
+
+func promptFly(cfg *config.Config) error {
+	if err := survey.AskOne(&survey.Password{Message: "Fly.io API token:"}, &cfg.FlyAPIToken,
+		survey.WithValidator(survey.Required),
+	); err != nil {
+		return fmt.Errorf("prompt cancelled: %w", err)
+	}
+
+	if err := survey.AskOne(&survey.Input{Message: "Fly.io organization slug:"}, &cfg.FlyOrgID,
+		survey.WithValidator(survey.Required),
+	); err != nil {
+		return fmt.Errorf("prompt cancelled: %w", err)
+	}
+
+	if err := survey.AskOne(&survey.Input{Message: "Fly.io region:", Default: "iad"}, &cfg.FlyRegion); err != nil {
+		return fmt.Errorf("prompt cancelled: %w", err)
+	}
+
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(initCmd)
-} 
\ No newline at end of file
+
+	initCmd.Flags().StringVar(&initProfile, "profile", "", "name of the profile to create or replace (default: \"default\")")
+}
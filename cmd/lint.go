@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"path/filepath"
 
+	"github.com/nmsde/docktor/internal/builder"
 	"github.com/nmsde/docktor/internal/config"
-	"github.com/nmsde/docktor/internal/gcp"
 	"github.com/spf13/cobra"
 )
 
@@ -17,8 +17,8 @@ var (
 var lintCmd = &cobra.Command{
 	Use:   "lint",
 	Short: "Lint a Dockerfile using Hadolint",
-	Long: `Lint a Dockerfile using Hadolint in Google Cloud Build.
-This command uploads your Dockerfile to Cloud Build and runs Hadolint to check for best practices and common issues.`,
+	Long: `Lint a Dockerfile using Hadolint via the configured builder backend.
+This command runs Hadolint against your Dockerfile to check for best practices and common issues.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Load configuration
 		cfg, err := config.LoadConfig()
@@ -26,11 +26,12 @@ This command uploads your Dockerfile to Cloud Build and runs Hadolint to check f
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 
-		// Initialize GCP client
-		client, err := gcp.NewClient(cfg.GCPProjectID, cfg.GCPServiceAccount, cfg.GCPServiceKeyPath)
+		// Initialize the builder backend (gcp, fly, or local)
+		bld, err := builder.New(cfg)
 		if err != nil {
-			return fmt.Errorf("failed to create GCP client: %w", err)
+			return fmt.Errorf("failed to initialize builder: %w", err)
 		}
+		defer bld.Close()
 
 		// Get absolute path for context
 		absContext, err := filepath.Abs(lintContext)
@@ -39,7 +40,7 @@ This command uploads your Dockerfile to Cloud Build and runs Hadolint to check f
 		}
 
 		// Lint the Dockerfile
-		result, err := client.LintDockerfile(cmd.Context(), absContext, lintFile)
+		result, err := bld.Lint(cmd.Context(), builder.LintRequest{ContextPath: absContext, DockerfilePath: lintFile})
 		if err != nil {
 			return fmt.Errorf("failed to lint Dockerfile: %w", err)
 		}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nmsde/docktor/internal/builder"
+	"gopkg.in/yaml.v3"
+)
+
+// matrixEntry is a single job within a --matrix file.
+type matrixEntry struct {
+	Context    string            `yaml:"context"`
+	Dockerfile string            `yaml:"dockerfile"`
+	BuildArgs  map[string]string `yaml:"build_args"`
+	Platform   string            `yaml:"platform"`
+	Tag        string            `yaml:"tag"`
+}
+
+// loadMatrix reads a --matrix file and turns each entry into a
+// builder.BuildRequest, resolving context/dockerfile paths relative to the
+// matrix file's own directory and keyed (for MatrixResult aggregation) by
+// entry.Tag.
+func loadMatrix(path string) ([]builder.BuildRequest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read matrix file: %w", err)
+	}
+
+	var entries []matrixEntry
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse matrix file: %w", err)
+	}
+
+	baseDir := filepath.Dir(path)
+	reqs := make([]builder.BuildRequest, 0, len(entries))
+	for i, e := range entries {
+		if e.Tag == "" {
+			return nil, fmt.Errorf("matrix entry %d: tag is required", i)
+		}
+
+		ctxPath := e.Context
+		if ctxPath == "" {
+			ctxPath = "."
+		}
+		if !filepath.IsAbs(ctxPath) {
+			ctxPath = filepath.Join(baseDir, ctxPath)
+		}
+
+		dockerfile := e.Dockerfile
+		if dockerfile == "" {
+			// No Dockerfile specified: default to the conventional
+			// Dockerfile at this entry's own context root, not the matrix
+			// file's directory - each entry is expected to have its own
+			// Dockerfile next to its own context.
+			dockerfile = filepath.Join(ctxPath, "Dockerfile")
+		} else if !filepath.IsAbs(dockerfile) {
+			dockerfile = filepath.Join(baseDir, dockerfile)
+		}
+
+		req := builder.BuildRequest{
+			ContextPath:    ctxPath,
+			DockerfilePath: dockerfile,
+			ImageTag:       e.Tag,
+			BuildArgs:      e.BuildArgs,
+			Platform:       e.Platform,
+			SBOMFormat:     sbomFormat,
+			ReportFormat:   reportFormat,
+			FailOn:         failOn,
+		}
+		if outputPath != "" {
+			req.OutputPath = fmt.Sprintf("%s-%s", outputPath, e.Tag)
+		}
+
+		reqs = append(reqs, req)
+	}
+
+	return reqs, nil
+}
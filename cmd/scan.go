@@ -1,25 +1,52 @@
 package cmd
 
 import (
-	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
 	"path/filepath"
 
+	"github.com/nmsde/docktor/internal/builder"
 	"github.com/nmsde/docktor/internal/config"
-	"github.com/nmsde/docktor/internal/gcp"
+	"github.com/nmsde/docktor/internal/scanner"
 	"github.com/spf13/cobra"
 )
 
 var (
 	contextPath    string
 	dockerfilePath string
+	sbomFormat     string
+	reportFormat   string
+	format         string
+	outputPath     string
+	failOn         string
+	matrixPath     string
+	parallelism    int
+	noProgress     bool
+	silent         bool
+	skipDBUpdate   bool
+	offlineScan    bool
 )
 
+// progressReporter builds the ProgressReporter a scan should report
+// through: a terminal bar by default, or SilentReporter{} when either
+// --no-progress or --silent is set (--silent additionally implies a
+// non-interactive run is expected, e.g. in CI).
+func progressReporter() scanner.ProgressReporter {
+	if noProgress || silent {
+		return scanner.SilentReporter{}
+	}
+	return scanner.NewTerminalReporter()
+}
+
 var scanCmd = &cobra.Command{
 	Use:   "scan",
 	Short: "Scan a Docker image for vulnerabilities",
-	Long: `Scan a Docker image for vulnerabilities using Google Cloud Build.
-The image will be built and scanned in the cloud, and the results will be displayed.`,
+	Long: `Scan a Docker image for vulnerabilities using the configured builder backend
+(Google Cloud Build, Fly.io Machines, or local Docker). The image will be built
+and scanned remotely or locally, and the results will be displayed.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Load configuration
 		cfg, err := config.LoadConfig()
@@ -27,10 +54,15 @@ The image will be built and scanned in the cloud, and the results will be displa
 			return fmt.Errorf("failed to load configuration: %w", err)
 		}
 
-		// Initialize Google Cloud Build client
-		client, err := gcp.NewClient(cfg.GCPProjectID, cfg.GCPServiceAccount, cfg.GCPServiceKeyPath)
+		// Initialize the builder backend (gcp, fly, or local)
+		bld, err := builder.New(cfg)
 		if err != nil {
-			return fmt.Errorf("failed to initialize Google Cloud Build client: %w", err)
+			return fmt.Errorf("failed to initialize builder: %w", err)
+		}
+		defer bld.Close()
+
+		if matrixPath != "" {
+			return runMatrixScan(cmd, bld, cfg)
 		}
 
 		// Get absolute paths
@@ -45,9 +77,28 @@ The image will be built and scanned in the cloud, and the results will be displa
 		}
 
 		// Start build and scan
-		fmt.Println("Starting build and scan...")
-		result, err := client.BuildAndScanImage(context.Background(), absContextPath, absDockerfilePath)
+		slog.Info("starting build and scan", "backend", cfg.Backend, "context", absContextPath)
+		req := builder.BuildRequest{
+			ContextPath:    absContextPath,
+			DockerfilePath: absDockerfilePath,
+			SecurityChecks: cfg.SecurityChecks,
+			SBOMFormat:     sbomFormat,
+			ReportFormat:   reportFormat,
+			OutputPath:     outputPath,
+			FailOn:         failOn,
+			Policy:         cfg.Policy,
+			Progress:       progressReporter(),
+			CacheDir:       cfg.CacheDir,
+			SkipDBUpdate:   skipDBUpdate,
+			OfflineScan:    offlineScan,
+		}
+		result, err := bld.BuildAndScan(cmd.Context(), req)
 		if err != nil {
+			var violation *builder.ErrPolicyViolation
+			if errors.As(err, &violation) {
+				fmt.Printf("\n❌ Policy gate failed: %s\n", violation.Error())
+				return err
+			}
 			return fmt.Errorf("failed to build and scan image: %w", err)
 		}
 
@@ -58,13 +109,113 @@ The image will be built and scanned in the cloud, and the results will be displa
 		fmt.Printf("End time: %s\n", result.EndTime)
 		fmt.Printf("Logs: %s\n", result.Logs)
 
-		// Cleanup
-		if err := client.Cleanup(context.Background(), result.ID); err != nil {
-			fmt.Printf("Warning: failed to cleanup build artifacts: %v\n", err)
-		}
+		return writeReport(result, format, outputPath)
+	},
+}
 
+// writeReport renders result's findings as the requested format (table,
+// html, json, sarif, or cyclonedx) and writes them to outputPath, or
+// stdout if outputPath is empty. It works the same way for every backend,
+// since builder.BuildResult.Vulnerabilities is already in the
+// backend-agnostic shape.
+func writeReport(result *builder.BuildResult, format, outputPath string) error {
+	if format == "" {
 		return nil
-	},
+	}
+
+	vulns := make([]scanner.Vulnerability, 0, len(result.Vulnerabilities))
+	for _, v := range result.Vulnerabilities {
+		vulns = append(vulns, scanner.Vulnerability{
+			ID:          v.VulnerabilityID,
+			Title:       v.Title,
+			Description: v.Description,
+			Severity:    scanner.Severity(v.Severity),
+			Package:     v.PkgName,
+			Version:     v.InstalledVersion,
+			FixedIn:     v.FixedVersion,
+		})
+	}
+	scanReport := &scanner.ScanReport{
+		ImageName:       result.ID,
+		ScanTime:        result.EndTime.Format("2006-01-02T15:04:05Z07:00"),
+		Vulnerabilities: vulns,
+	}
+
+	var data []byte
+	var err error
+	ext := ".txt"
+	switch format {
+	case "table":
+		data = []byte(scanReport.GenerateTable())
+	case "html":
+		data = []byte(scanReport.GenerateHTML())
+		ext = ".html"
+	case "json":
+		data, err = json.MarshalIndent(scanReport, "", "  ")
+		ext = ".json"
+	case "sarif":
+		data, err = scanReport.GenerateSARIF()
+		ext = ".sarif.json"
+	case "cyclonedx":
+		data, err = scanReport.GenerateCycloneDX()
+		ext = ".cdx.json"
+	default:
+		return fmt.Errorf("unknown report format %q: must be one of table, html, json, sarif, cyclonedx", format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to generate %s report: %w", format, err)
+	}
+
+	if outputPath == "" {
+		fmt.Println(string(data))
+		return nil
+	}
+
+	path := outputPath + ext
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s report: %w", format, err)
+	}
+	fmt.Printf("Report written to %s\n", path)
+
+	return nil
+}
+
+// runMatrixScan builds and scans every job described by --matrix in
+// parallel (bounded by --parallelism), printing one summary line per tag.
+func runMatrixScan(cmd *cobra.Command, bld builder.Builder, cfg *config.Config) error {
+	reqs, err := loadMatrix(matrixPath)
+	if err != nil {
+		return err
+	}
+	progress := progressReporter()
+	for i := range reqs {
+		reqs[i].SecurityChecks = cfg.SecurityChecks
+		reqs[i].Policy = cfg.Policy
+		reqs[i].Progress = progress
+		reqs[i].CacheDir = cfg.CacheDir
+		reqs[i].SkipDBUpdate = skipDBUpdate
+		reqs[i].OfflineScan = offlineScan
+	}
+
+	slog.Info("starting matrix build and scan", "jobs", len(reqs), "parallelism", parallelism)
+	matrix, err := bld.BuildAndScanMany(cmd.Context(), reqs, parallelism)
+	if err != nil {
+		return fmt.Errorf("failed to run build matrix: %w", err)
+	}
+
+	for _, req := range reqs {
+		if result, ok := matrix.Results[req.ImageTag]; ok {
+			fmt.Printf("\n✅ %s: %s (%d finding(s))\n", req.ImageTag, result.Status, len(result.Vulnerabilities))
+			continue
+		}
+		fmt.Printf("\n❌ %s: %v\n", req.ImageTag, matrix.Errors[req.ImageTag])
+	}
+
+	if len(matrix.Errors) > 0 {
+		return fmt.Errorf("%d of %d matrix job(s) failed", len(matrix.Errors), len(reqs))
+	}
+
+	return nil
 }
 
 func init() {
@@ -72,4 +223,15 @@ func init() {
 
 	scanCmd.Flags().StringVarP(&contextPath, "context", "c", ".", "Path to the build context")
 	scanCmd.Flags().StringVarP(&dockerfilePath, "file", "f", "Dockerfile", "Path to the Dockerfile")
+	scanCmd.Flags().StringVar(&sbomFormat, "sbom-format", "", "SBOM format to generate (spdx-json|cyclonedx-json); gcp backend only, no-op on fly/local")
+	scanCmd.Flags().StringVar(&reportFormat, "report-format", "", "Vulnerability report format to generate (sarif|cyclonedx|json); gcp backend only")
+	scanCmd.Flags().StringVar(&format, "format", "", "Render the scan result as this format (table|html|json|sarif|cyclonedx) and write it to --output, or stdout if unset")
+	scanCmd.Flags().StringVar(&outputPath, "output", "", "Path (without extension) to write the SBOM/report/format artifacts to")
+	scanCmd.Flags().StringVar(&failOn, "fail-on", "", "Exit non-zero if any finding meets or exceeds this severity (critical|high|medium|low)")
+	scanCmd.Flags().StringVar(&matrixPath, "matrix", "", "Path to a YAML file listing multiple {context, dockerfile, build_args, platform, tag} jobs to build and scan in parallel")
+	scanCmd.Flags().IntVar(&parallelism, "parallelism", 3, "Maximum number of matrix jobs to run concurrently")
+	scanCmd.Flags().BoolVar(&noProgress, "no-progress", false, "Disable the scan progress bar")
+	scanCmd.Flags().BoolVar(&silent, "silent", false, "Disable the scan progress bar and run non-interactively, for CI")
+	scanCmd.Flags().BoolVar(&skipDBUpdate, "skip-db-update", false, "Reuse the cached vulnerability DB instead of checking for an update")
+	scanCmd.Flags().BoolVar(&offlineScan, "offline-scan", false, "Scan fully offline, including language-specific advisory lookups (implies --skip-db-update)")
 } 
\ No newline at end of file
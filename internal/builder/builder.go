@@ -0,0 +1,176 @@
+// Package builder abstracts "build a Docker image and scan it" and
+// "lint a Dockerfile" behind a single interface so the CLI can target
+// Google Cloud Build, Fly.io Machines, or the local Docker daemon
+// interchangeably.
+package builder
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nmsde/docktor/internal/policy"
+	"github.com/nmsde/docktor/internal/scanner"
+)
+
+// BuildRequest describes a build+scan job.
+type BuildRequest struct {
+	ContextPath    string
+	DockerfilePath string
+	ImageTag       string
+	BuildArgs      map[string]string
+	Platform       string
+	SecurityChecks []string
+	SBOMFormat     string
+	ReportFormat   string
+	OutputPath     string
+	FailOn         string
+	Policy         policy.Policy
+	// Progress reports scan phases as they happen. Defaults to a
+	// SilentReporter if left nil.
+	Progress scanner.ProgressReporter
+	// CacheDir, SkipDBUpdate, and OfflineScan configure offline/cached
+	// scanning; see scanner.ScanOptions.
+	CacheDir     string
+	SkipDBUpdate bool
+	OfflineScan  bool
+}
+
+// LintRequest describes a Dockerfile lint job.
+type LintRequest struct {
+	ContextPath    string
+	DockerfilePath string
+}
+
+// Vulnerability is a single finding from a BuildAndScan run, in a shape
+// common to every backend.
+type Vulnerability struct {
+	VulnerabilityID  string
+	PkgName          string
+	InstalledVersion string
+	FixedVersion     string
+	Severity         string
+	Title            string
+	Description      string
+}
+
+// BuildResult is the outcome of a BuildAndScan run.
+type BuildResult struct {
+	ID              string
+	Status          string
+	StartTime       time.Time
+	EndTime         time.Time
+	Logs            string
+	Vulnerabilities []Vulnerability
+}
+
+// LintIssue is a single Hadolint-style finding.
+type LintIssue struct {
+	Line    int
+	Message string
+	Level   string
+	Code    string
+}
+
+// LintResult is the outcome of a Lint run.
+type LintResult struct {
+	Issues []LintIssue
+}
+
+// ErrPolicyViolation is returned by BuildAndScan when a scan's findings
+// meet or exceed the request's FailOn severity threshold, independent of
+// which backend produced them.
+type ErrPolicyViolation struct {
+	Severity string
+	Count    int
+}
+
+func (e *ErrPolicyViolation) Error() string {
+	return fmt.Sprintf("%d finding(s) at or above %s severity", e.Count, e.Severity)
+}
+
+// gatePolicy evaluates p against report, printing its breakdown, and
+// returns an ErrPolicyViolation naming the worst-failing severity if the
+// policy doesn't pass. It is shared by every backend (gcp, fly, local) so
+// the gating behavior is identical regardless of where the scan ran, once
+// each backend's own results are converted to a scanner.ScanReport.
+func gatePolicy(p policy.Policy, report *scanner.ScanReport) error {
+	if !p.Enabled() {
+		return nil
+	}
+
+	result := p.Evaluate(report)
+	result.PrintSummary()
+	if result.Pass {
+		return nil
+	}
+
+	for _, rule := range result.Rules {
+		if !rule.Pass {
+			return &ErrPolicyViolation{Severity: rule.Severity, Count: rule.Count}
+		}
+	}
+	return &ErrPolicyViolation{}
+}
+
+// MatrixResult is the aggregate outcome of a BuildAndScanMany run: one
+// BuildResult per request that built and scanned successfully, and one
+// error per request that didn't, both keyed by the request's ImageTag.
+type MatrixResult struct {
+	Results map[string]*BuildResult
+	Errors  map[string]error
+}
+
+// Builder builds and scans Docker images, and lints Dockerfiles, against a
+// specific backend (Google Cloud Build, Fly.io Machines, local Docker).
+type Builder interface {
+	BuildAndScan(ctx context.Context, req BuildRequest) (*BuildResult, error)
+	// BuildAndScanMany runs a matrix of build+scan jobs with up to
+	// parallelism running at once, aggregating their outcomes by tag
+	// rather than failing the whole run on the first error.
+	BuildAndScanMany(ctx context.Context, reqs []BuildRequest, parallelism int) (*MatrixResult, error)
+	Lint(ctx context.Context, req LintRequest) (*LintResult, error)
+	Close() error
+}
+
+// runMatrix fans req out across up to parallelism goroutines, invoking one
+// for each and collecting its outcome into a MatrixResult keyed by
+// req.ImageTag. It is shared by every Builder implementation so the
+// worker-pool bookkeeping (and tag-keying convention) lives in one place.
+func runMatrix(reqs []BuildRequest, parallelism int, one func(BuildRequest) (*BuildResult, error)) *MatrixResult {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	result := &MatrixResult{
+		Results: make(map[string]*BuildResult, len(reqs)),
+		Errors:  make(map[string]error),
+	}
+	var mu sync.Mutex
+
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for _, req := range reqs {
+		req := req
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			res, err := one(req)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Errors[req.ImageTag] = err
+			} else {
+				result.Results[req.ImageTag] = res
+			}
+		}()
+	}
+	wg.Wait()
+
+	return result
+}
@@ -0,0 +1,26 @@
+package builder
+
+import (
+	"fmt"
+
+	"github.com/nmsde/docktor/internal/config"
+	"github.com/nmsde/docktor/internal/gcp"
+)
+
+// New constructs the Builder selected by cfg.Backend.
+func New(cfg *config.Config) (Builder, error) {
+	switch cfg.Backend {
+	case "", "gcp":
+		client, err := gcp.NewClient(cfg.GCPProjectID, cfg.GCPServiceAccount, cfg.GCPServiceKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCP client: %w", err)
+		}
+		return NewGCPBuilder(client), nil
+	case "fly":
+		return NewFlyBuilder(cfg.FlyAPIToken, cfg.FlyOrgID, cfg.FlyRegion)
+	case "local":
+		return NewLocalBuilder(), nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q: must be one of gcp, fly, local", cfg.Backend)
+	}
+}
@@ -0,0 +1,218 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nmsde/docktor/internal/fly"
+	"github.com/nmsde/docktor/internal/logging"
+	"github.com/nmsde/docktor/internal/scanner"
+)
+
+// flyBuilder targets Fly.io Machines. All jobs run in a single shared app
+// (created lazily on first use) so provisioning cost is amortized across a
+// matrix run instead of paid per job, and machines are returned to an idle
+// pool for reuse rather than destroyed after every build.
+type flyBuilder struct {
+	client *fly.Client
+	orgID  string
+	region string
+
+	appOnce sync.Once
+	appID   string
+	appErr  error
+
+	mu          sync.Mutex
+	idle        []string
+	provisioned map[string]bool
+}
+
+// NewFlyBuilder creates a Builder backed by Fly.io Machines.
+func NewFlyBuilder(apiToken, orgID, region string) (Builder, error) {
+	client, err := fly.NewClient(apiToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Fly.io client: %w", err)
+	}
+
+	return &flyBuilder{
+		client:      client,
+		orgID:       orgID,
+		region:      region,
+		provisioned: make(map[string]bool),
+	}, nil
+}
+
+// ensureApp creates the builder's shared app on first use and reuses it for
+// every subsequent job.
+func (b *flyBuilder) ensureApp() (string, error) {
+	b.appOnce.Do(func() {
+		appID := fmt.Sprintf("docktor-%s", uuid.New().String()[:8])
+		if _, err := b.client.CreateApp(appID, b.orgID); err != nil {
+			b.appErr = fmt.Errorf("failed to create Fly.io app: %w", err)
+			return
+		}
+		b.appID = appID
+	})
+	return b.appID, b.appErr
+}
+
+// acquireMachine returns an idle machine from the pool, provisioning a new
+// one if none is available.
+func (b *flyBuilder) acquireMachine() (*fly.Machine, error) {
+	appID, err := b.ensureApp()
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	if n := len(b.idle); n > 0 {
+		id := b.idle[n-1]
+		b.idle = b.idle[:n-1]
+		b.mu.Unlock()
+		return &fly.Machine{ID: id, AppID: appID}, nil
+	}
+	b.mu.Unlock()
+
+	machine, err := b.client.CreateMachine(appID, b.region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to provision Fly.io machine: %w", err)
+	}
+
+	b.mu.Lock()
+	b.provisioned[machine.ID] = true
+	b.mu.Unlock()
+
+	return machine, nil
+}
+
+// releaseMachine returns a machine to the idle pool for reuse by the next
+// job instead of destroying it.
+func (b *flyBuilder) releaseMachine(machineID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.idle = append(b.idle, machineID)
+}
+
+// watchForCancellation tears the whole pool down as soon as ctx is
+// cancelled (e.g. Ctrl-C), so no machine or app is left running just
+// because a build was interrupted mid-flight.
+func (b *flyBuilder) watchForCancellation(ctx context.Context) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = b.Close()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+func (b *flyBuilder) runJob(ctx context.Context, req BuildRequest) (*BuildResult, error) {
+	startTime := time.Now()
+
+	machine, err := b.acquireMachine()
+	if err != nil {
+		return nil, err
+	}
+	defer b.releaseMachine(machine.ID)
+
+	if err := b.client.UploadProject(machine.ID, req.ContextPath); err != nil {
+		return nil, fmt.Errorf("failed to upload build context: %w", err)
+	}
+
+	buildOpts := fly.BuildImageOptions{BuildArgs: req.BuildArgs, Platform: req.Platform}
+	imageName, err := b.client.BuildImage(machine.ID, req.ContextPath, req.DockerfilePath, buildOpts, logging.NewRedactingWriter(os.Stdout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build image: %w", err)
+	}
+
+	checks := make([]scanner.Check, 0, len(req.SecurityChecks))
+	for _, c := range req.SecurityChecks {
+		checks = append(checks, scanner.Check(c))
+	}
+	trivyScanner := scanner.NewTrivyScanner(fly.NewRemoteRunner(b.client, machine.ID), req.Progress)
+	scanReport, err := trivyScanner.ScanImage(ctx, imageName, scanner.ScanOptions{
+		Checks:       checks,
+		SkipDBUpdate: req.SkipDBUpdate,
+		OfflineScan:  req.OfflineScan,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan image: %w", err)
+	}
+
+	if err := gatePolicy(req.Policy, scanReport); err != nil {
+		return nil, err
+	}
+
+	vulns := make([]Vulnerability, 0, len(scanReport.Vulnerabilities))
+	for _, v := range scanReport.Vulnerabilities {
+		vulns = append(vulns, Vulnerability{
+			VulnerabilityID:  v.ID,
+			PkgName:          v.Package,
+			InstalledVersion: v.Version,
+			FixedVersion:     v.FixedIn,
+			Severity:         string(v.Severity),
+			Title:            v.Title,
+			Description:      v.Description,
+		})
+	}
+
+	return &BuildResult{
+		ID:              imageName,
+		Status:          "SUCCESS",
+		StartTime:       startTime,
+		EndTime:         time.Now(),
+		Logs:            fmt.Sprintf("fly machine %s (app %s)", machine.ID, machine.AppID),
+		Vulnerabilities: vulns,
+	}, nil
+}
+
+func (b *flyBuilder) BuildAndScan(ctx context.Context, req BuildRequest) (*BuildResult, error) {
+	stop := b.watchForCancellation(ctx)
+	defer stop()
+	defer b.Close()
+
+	return b.runJob(ctx, req)
+}
+
+func (b *flyBuilder) BuildAndScanMany(ctx context.Context, reqs []BuildRequest, parallelism int) (*MatrixResult, error) {
+	stop := b.watchForCancellation(ctx)
+	defer stop()
+	defer b.Close()
+
+	return runMatrix(reqs, parallelism, func(req BuildRequest) (*BuildResult, error) {
+		return b.runJob(ctx, req)
+	}), nil
+}
+
+func (b *flyBuilder) Lint(ctx context.Context, req LintRequest) (*LintResult, error) {
+	return nil, fmt.Errorf("linting is not yet supported on the fly backend")
+}
+
+// Close destroys every machine this builder has ever provisioned, idle or
+// not, along with the shared app, so no Fly.io resources outlive the run.
+func (b *flyBuilder) Close() error {
+	b.mu.Lock()
+	machines := make([]string, 0, len(b.provisioned))
+	for id := range b.provisioned {
+		machines = append(machines, id)
+	}
+	b.provisioned = make(map[string]bool)
+	b.idle = nil
+	appID := b.appID
+	b.mu.Unlock()
+
+	for _, id := range machines {
+		_ = b.client.DestroyMachine(id)
+	}
+	if appID != "" {
+		_ = b.client.DestroyApp(appID)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nmsde/docktor/internal/gcp"
+	"github.com/nmsde/docktor/internal/scanner"
+)
+
+// gcpBuilder targets Google Cloud Build.
+type gcpBuilder struct {
+	client *gcp.Client
+}
+
+// NewGCPBuilder wraps an existing Google Cloud Build client as a Builder.
+func NewGCPBuilder(client *gcp.Client) Builder {
+	return &gcpBuilder{client: client}
+}
+
+func (b *gcpBuilder) BuildAndScan(ctx context.Context, req BuildRequest) (*BuildResult, error) {
+	opts := &gcp.BuildOptions{
+		SBOMFormat:   req.SBOMFormat,
+		ReportFormat: req.ReportFormat,
+		OutputPath:   req.OutputPath,
+		FailOn:       req.FailOn,
+	}
+
+	result, err := b.client.BuildAndScanImage(ctx, req.ContextPath, req.DockerfilePath, opts)
+	if err != nil {
+		var violation *gcp.ErrPolicyViolation
+		if errors.As(err, &violation) {
+			return nil, &ErrPolicyViolation{Severity: violation.Severity, Count: violation.Count}
+		}
+		return nil, err
+	}
+
+	if err := b.client.Cleanup(ctx, result.ID); err != nil {
+		fmt.Printf("Warning: failed to cleanup build artifacts: %v\n", err)
+	}
+
+	vulns := make([]Vulnerability, 0, len(result.ScanResults.Vulnerabilities))
+	scanVulns := make([]scanner.Vulnerability, 0, len(result.ScanResults.Vulnerabilities))
+	for _, v := range result.ScanResults.Vulnerabilities {
+		vulns = append(vulns, Vulnerability{
+			VulnerabilityID:  v.VulnerabilityID,
+			PkgName:          v.PkgName,
+			InstalledVersion: v.InstalledVersion,
+			FixedVersion:     v.FixedVersion,
+			Severity:         v.Severity,
+			Title:            v.Title,
+			Description:      v.Description,
+		})
+		scanVulns = append(scanVulns, scanner.Vulnerability{
+			ID:          v.VulnerabilityID,
+			Title:       v.Title,
+			Description: v.Description,
+			Severity:    scanner.Severity(v.Severity),
+			Package:     v.PkgName,
+			Version:     v.InstalledVersion,
+			FixedIn:     v.FixedVersion,
+		})
+	}
+
+	// Apply the richer builder-wide Policy (ignore rules, allowlisted
+	// packages, per-severity counts) on top of BuildOptions.FailOn's
+	// simple threshold, the same as the fly and local backends.
+	if err := gatePolicy(req.Policy, &scanner.ScanReport{ImageName: result.ID, Vulnerabilities: scanVulns}); err != nil {
+		return nil, err
+	}
+
+	return &BuildResult{
+		ID:              result.ID,
+		Status:          result.Status,
+		StartTime:       result.StartTime,
+		EndTime:         result.EndTime,
+		Logs:            result.Logs,
+		Vulnerabilities: vulns,
+	}, nil
+}
+
+func (b *gcpBuilder) BuildAndScanMany(ctx context.Context, reqs []BuildRequest, parallelism int) (*MatrixResult, error) {
+	return runMatrix(reqs, parallelism, func(req BuildRequest) (*BuildResult, error) {
+		return b.BuildAndScan(ctx, req)
+	}), nil
+}
+
+func (b *gcpBuilder) Lint(ctx context.Context, req LintRequest) (*LintResult, error) {
+	result, err := b.client.LintDockerfile(ctx, req.ContextPath, req.DockerfilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lint Dockerfile: %w", err)
+	}
+
+	issues := make([]LintIssue, 0, len(result.Issues))
+	for _, i := range result.Issues {
+		issues = append(issues, LintIssue{Line: i.Line, Message: i.Message, Level: i.Level, Code: i.Code})
+	}
+
+	return &LintResult{Issues: issues}, nil
+}
+
+func (b *gcpBuilder) Close() error {
+	return nil
+}
@@ -0,0 +1,125 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nmsde/docktor/internal/scanner"
+)
+
+// localBuilder shells out to the local Docker daemon and a local Trivy
+// binary, for offline use or CI runners without cloud credentials.
+type localBuilder struct{}
+
+// NewLocalBuilder creates a Builder backed by the local Docker daemon.
+func NewLocalBuilder() Builder {
+	return &localBuilder{}
+}
+
+func (b *localBuilder) BuildAndScan(ctx context.Context, req BuildRequest) (*BuildResult, error) {
+	startTime := time.Now()
+
+	imageTag := req.ImageTag
+	if imageTag == "" {
+		imageTag = fmt.Sprintf("docktor-local-%s", uuid.New().String())
+	}
+
+	dockerArgs := []string{"build", "-t", imageTag, "-f", req.DockerfilePath}
+	if req.Platform != "" {
+		dockerArgs = append(dockerArgs, "--platform", req.Platform)
+	}
+	for k, v := range req.BuildArgs {
+		dockerArgs = append(dockerArgs, "--build-arg", fmt.Sprintf("%s=%s", k, v))
+	}
+	dockerArgs = append(dockerArgs, req.ContextPath)
+	buildCmd := exec.CommandContext(ctx, "docker", dockerArgs...)
+	var buildStderr bytes.Buffer
+	buildCmd.Stderr = &buildStderr
+	if err := buildCmd.Run(); err != nil {
+		return nil, fmt.Errorf("docker build failed: %w: %s", err, buildStderr.String())
+	}
+
+	checks := make([]scanner.Check, 0, len(req.SecurityChecks))
+	for _, c := range req.SecurityChecks {
+		checks = append(checks, scanner.Check(c))
+	}
+	trivyScanner := scanner.NewTrivyScanner(scanner.NewBinaryRunner(), req.Progress)
+	scanReport, err := trivyScanner.ScanImage(ctx, imageTag, scanner.ScanOptions{
+		Checks:       checks,
+		CacheDir:     req.CacheDir,
+		SkipDBUpdate: req.SkipDBUpdate,
+		OfflineScan:  req.OfflineScan,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("trivy scan failed: %w", err)
+	}
+
+	if err := gatePolicy(req.Policy, scanReport); err != nil {
+		return nil, err
+	}
+
+	vulns := make([]Vulnerability, 0, len(scanReport.Vulnerabilities))
+	for _, v := range scanReport.Vulnerabilities {
+		vulns = append(vulns, Vulnerability{
+			VulnerabilityID:  v.ID,
+			PkgName:          v.Package,
+			InstalledVersion: v.Version,
+			FixedVersion:     v.FixedIn,
+			Severity:         string(v.Severity),
+			Title:            v.Title,
+			Description:      v.Description,
+		})
+	}
+
+	return &BuildResult{
+		ID:              imageTag,
+		Status:          "SUCCESS",
+		StartTime:       startTime,
+		EndTime:         time.Now(),
+		Logs:            "local docker build",
+		Vulnerabilities: vulns,
+	}, nil
+}
+
+func (b *localBuilder) BuildAndScanMany(ctx context.Context, reqs []BuildRequest, parallelism int) (*MatrixResult, error) {
+	return runMatrix(reqs, parallelism, func(req BuildRequest) (*BuildResult, error) {
+		return b.BuildAndScan(ctx, req)
+	}), nil
+}
+
+func (b *localBuilder) Lint(ctx context.Context, req LintRequest) (*LintResult, error) {
+	cmd := exec.CommandContext(ctx, "hadolint", "--format", "json", req.DockerfilePath)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	// Hadolint exits non-zero when it finds issues, so only treat an
+	// unparsable result as a real failure.
+	_ = cmd.Run()
+
+	var hadolintIssues []struct {
+		Line    int    `json:"line"`
+		Message string `json:"message"`
+		Level   string `json:"level"`
+		Code    string `json:"code"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &hadolintIssues); err != nil {
+		return nil, fmt.Errorf("failed to run hadolint: %w: %s", err, stderr.String())
+	}
+
+	issues := make([]LintIssue, 0, len(hadolintIssues))
+	for _, issue := range hadolintIssues {
+		issues = append(issues, LintIssue{Line: issue.Line, Message: issue.Message, Level: issue.Level, Code: issue.Code})
+	}
+
+	return &LintResult{Issues: issues}, nil
+}
+
+func (b *localBuilder) Close() error {
+	return nil
+}
@@ -5,22 +5,71 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/nmsde/docktor/internal/policy"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
+	// Backend selects which builder.Builder implementation to use: "gcp"
+	// (default), "fly", or "local".
+	Backend string `mapstructure:"backend" yaml:"backend,omitempty"`
+
 	// Google Cloud Build configuration
-	GCPProjectID        string `mapstructure:"gcp_project_id"`
-	GCPRegion          string `mapstructure:"gcp_region"`
-	GCPServiceAccount  string `mapstructure:"gcp_service_account"`
-	GCPServiceKeyPath  string `mapstructure:"gcp_service_key_path"`
+	GCPProjectID      string `mapstructure:"gcp_project_id" yaml:"gcp_project_id,omitempty"`
+	GCPRegion         string `mapstructure:"gcp_region" yaml:"gcp_region,omitempty"`
+	GCPServiceAccount string `mapstructure:"gcp_service_account" yaml:"gcp_service_account,omitempty"`
+	GCPServiceKeyPath string `mapstructure:"gcp_service_key_path" yaml:"gcp_service_key_path,omitempty"`
+
+	// Fly.io configuration
+	FlyAPIToken string `mapstructure:"fly_api_token" yaml:"fly_api_token,omitempty"`
+	FlyOrgID    string `mapstructure:"fly_org_id" yaml:"fly_org_id,omitempty"`
+	FlyRegion   string `mapstructure:"fly_region" yaml:"fly_region,omitempty"`
 
 	// Scanner configuration
-	Severity string `mapstructure:"severity"`
-	Timeout  int    `mapstructure:"timeout"`
+	Severity       string   `mapstructure:"severity" yaml:"severity,omitempty"`
+	Timeout        int      `mapstructure:"timeout" yaml:"timeout,omitempty"`
+	SecurityChecks []string `mapstructure:"security_checks" yaml:"security_checks,omitempty"`
+
+	// Policy gates scans on a per-severity finding count, independent of
+	// the simpler --fail-on threshold.
+	Policy policy.Policy `mapstructure:"policy" yaml:"policy,omitempty"`
+
+	// CacheDir holds the Trivy vulnerability DB and cached scan results,
+	// for offline/air-gapped use and to skip re-scanning unchanged
+	// images. Defaults to ~/.docktor/cache.
+	CacheDir string `mapstructure:"cache_dir" yaml:"cache_dir,omitempty"`
 }
 
 func LoadConfig() (*Config, error) {
+	// .docktor.yaml using named profiles takes precedence over the legacy
+	// flat key/value format.
+	if hasProfiles() {
+		pf, err := LoadProfileFile()
+		if err != nil {
+			return nil, err
+		}
+
+		config, err := pf.Current()
+		if err != nil {
+			return nil, err
+		}
+
+		// pf.Current() returns the profile's Config straight from YAML,
+		// bypassing viper entirely. Re-apply the global --backend flag (the
+		// only thing cobra/viper binds ahead of this point) so it still
+		// overrides the profile the way it does in the legacy config path
+		// below.
+		if backend := viper.GetString("backend"); backend != "" {
+			config.Backend = backend
+		}
+
+		if err := validate(config); err != nil {
+			return nil, err
+		}
+
+		return config, nil
+	}
+
 	// First, try to load from .docktor.env in the current directory
 	if err := loadEnvFile(".docktor.env"); err != nil {
 		return nil, fmt.Errorf("failed to load .docktor.env: %w", err)
@@ -46,20 +95,58 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
-	// Validate required fields
-	if config.GCPProjectID == "" {
-		return nil, fmt.Errorf("gcp_project_id is required")
+	if err := validate(config); err != nil {
+		return nil, err
 	}
 
-	// Validate GCP service account configuration
-	if config.GCPServiceAccount == "" && config.GCPServiceKeyPath == "" {
-		// Check for GOOGLE_APPLICATION_CREDENTIALS environment variable
-		if os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") == "" {
-			return nil, fmt.Errorf("either gcp_service_account or gcp_service_key_path must be set, or GOOGLE_APPLICATION_CREDENTIALS environment variable must be set")
+	return config, nil
+}
+
+// validate fills in backend-specific defaults and checks that the fields
+// the selected backend needs are present.
+func validate(config *Config) error {
+	if config.Backend == "" {
+		config.Backend = "gcp"
+	}
+
+	switch config.Backend {
+	case "gcp":
+		if config.GCPProjectID == "" {
+			return fmt.Errorf("gcp_project_id is required")
+		}
+
+		// Validate GCP service account configuration
+		if config.GCPServiceAccount == "" && config.GCPServiceKeyPath == "" {
+			// Check for GOOGLE_APPLICATION_CREDENTIALS environment variable
+			if os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") == "" {
+				return fmt.Errorf("either gcp_service_account or gcp_service_key_path must be set, or GOOGLE_APPLICATION_CREDENTIALS environment variable must be set")
+			}
+		}
+	case "fly":
+		if config.FlyAPIToken == "" {
+			return fmt.Errorf("fly_api_token is required when backend is \"fly\"")
+		}
+		if config.FlyOrgID == "" {
+			return fmt.Errorf("fly_org_id is required when backend is \"fly\"")
 		}
+		if config.FlyRegion == "" {
+			config.FlyRegion = "iad"
+		}
+	case "local":
+		// No credentials required.
+	default:
+		return fmt.Errorf("unknown backend %q: must be one of gcp, fly, local", config.Backend)
 	}
 
-	return config, nil
+	if config.CacheDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to get home directory: %w", err)
+		}
+		config.CacheDir = filepath.Join(homeDir, ".docktor", "cache")
+	}
+
+	return nil
 }
 
 func loadEnvFile(filename string) error {
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultProfileName is the profile used when none is specified.
+const DefaultProfileName = "default"
+
+const profileFileName = ".docktor.yaml"
+
+// ProfileFile is the on-disk shape of .docktor.yaml once it holds more
+// than one named profile (e.g. "default", "staging").
+type ProfileFile struct {
+	CurrentProfile string             `yaml:"current_profile"`
+	Profiles       map[string]*Config `yaml:"profiles"`
+}
+
+// LoadProfileFile reads .docktor.yaml as a multi-profile file. A missing
+// file returns an empty ProfileFile rather than an error, so callers can
+// populate it (e.g. from `docktor init`).
+func LoadProfileFile() (*ProfileFile, error) {
+	content, err := os.ReadFile(profileFileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &ProfileFile{CurrentProfile: DefaultProfileName, Profiles: map[string]*Config{}}, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", profileFileName, err)
+	}
+
+	var pf ProfileFile
+	if err := yaml.Unmarshal(content, &pf); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", profileFileName, err)
+	}
+	if pf.Profiles == nil {
+		pf.Profiles = map[string]*Config{}
+	}
+	if pf.CurrentProfile == "" {
+		pf.CurrentProfile = DefaultProfileName
+	}
+
+	return &pf, nil
+}
+
+// hasProfiles reports whether .docktor.yaml exists and declares a
+// "profiles" section, as opposed to the legacy flat key/value format.
+func hasProfiles() bool {
+	content, err := os.ReadFile(profileFileName)
+	if err != nil {
+		return false
+	}
+
+	var probe struct {
+		Profiles map[string]*Config `yaml:"profiles"`
+	}
+	if err := yaml.Unmarshal(content, &probe); err != nil {
+		return false
+	}
+
+	return len(probe.Profiles) > 0
+}
+
+// Save writes the profile file to .docktor.yaml.
+func (pf *ProfileFile) Save() error {
+	data, err := yaml.Marshal(pf)
+	if err != nil {
+		return fmt.Errorf("failed to marshal profiles: %w", err)
+	}
+
+	if err := os.WriteFile(profileFileName, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", profileFileName, err)
+	}
+
+	return nil
+}
+
+// Use switches the current profile, failing if name is not defined.
+func (pf *ProfileFile) Use(name string) error {
+	if _, ok := pf.Profiles[name]; !ok {
+		return fmt.Errorf("profile %q not found", name)
+	}
+
+	pf.CurrentProfile = name
+	return nil
+}
+
+// Current returns the Config for the current profile.
+func (pf *ProfileFile) Current() (*Config, error) {
+	cfg, ok := pf.Profiles[pf.CurrentProfile]
+	if !ok {
+		return nil, fmt.Errorf("current profile %q not found in %s", pf.CurrentProfile, profileFileName)
+	}
+
+	return cfg, nil
+}
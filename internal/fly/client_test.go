@@ -0,0 +1,63 @@
+package fly
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestBuildContextArchiveIsDeterministic proves buildContextArchive produces
+// the same digest for two directories with byte-identical file contents but
+// different filesystem metadata (mtimes; uid/gid aren't adjustable without
+// root in a test environment). This is the property UploadContext's
+// digest-keyed skip-if-already-uploaded check relies on: a CI checkout of an
+// unchanged tree must hash the same as the last run even though every file
+// got a fresh mtime.
+func TestBuildContextArchiveIsDeterministic(t *testing.T) {
+	mkTree := func(mtime time.Time) string {
+		t.Helper()
+		root := t.TempDir()
+
+		if err := os.WriteFile(filepath.Join(root, "Dockerfile"), []byte("FROM scratch\n"), 0644); err != nil {
+			t.Fatalf("failed to write Dockerfile: %v", err)
+		}
+		if err := os.MkdirAll(filepath.Join(root, "pkg"), 0755); err != nil {
+			t.Fatalf("failed to create pkg dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(root, "pkg", "main.go"), []byte("package main\n"), 0644); err != nil {
+			t.Fatalf("failed to write main.go: %v", err)
+		}
+
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			return os.Chtimes(path, mtime, mtime)
+		})
+		if err != nil {
+			t.Fatalf("failed to set mtimes: %v", err)
+		}
+
+		return root
+	}
+
+	rootA := mkTree(time.Now().Add(-48 * time.Hour))
+	rootB := mkTree(time.Now())
+
+	archiveA, digestA, err := buildContextArchive(rootA)
+	if err != nil {
+		t.Fatalf("buildContextArchive(rootA) failed: %v", err)
+	}
+	defer os.Remove(archiveA)
+
+	archiveB, digestB, err := buildContextArchive(rootB)
+	if err != nil {
+		t.Fatalf("buildContextArchive(rootB) failed: %v", err)
+	}
+	defer os.Remove(archiveB)
+
+	if digestA != digestB {
+		t.Fatalf("expected identical digests for byte-identical trees with different mtimes, got %s != %s", digestA, digestB)
+	}
+}
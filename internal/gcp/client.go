@@ -4,21 +4,30 @@ import (
 	"archive/tar"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
 	"cloud.google.com/go/storage"
 	"github.com/fatih/color"
 	"github.com/google/uuid"
+	"github.com/nmsde/docktor/internal/logging"
+	"github.com/nmsde/docktor/internal/report"
 	"github.com/sabhiram/go-gitignore"
 	"google.golang.org/api/cloudbuild/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
@@ -35,6 +44,10 @@ type BuildResult struct {
 	EndTime   time.Time
 	Logs      string
 	ScanResults *ScanResults
+	// ContextDigest is the SHA-256 digest of the uploaded build context
+	// archive (see createAndUploadContext). Callers can compare it across
+	// runs to tell whether the source tree actually changed.
+	ContextDigest string
 }
 
 type ScanResults struct {
@@ -71,6 +84,10 @@ func NewClient(projectID, serviceAccount, serviceKeyPath string) (*Client, error
 			return nil, fmt.Errorf("invalid service account key file: %w", err)
 		}
 
+		// Keep the key contents out of any logs/output written through
+		// a logging.RedactingWriter.
+		logging.RegisterSecret(string(keyFile))
+
 		// Add the credentials option
 		opts = append(opts, option.WithCredentialsFile(serviceKeyPath))
 	}
@@ -94,7 +111,35 @@ func NewClient(projectID, serviceAccount, serviceKeyPath string) (*Client, error
 	}, nil
 }
 
-func (c *Client) BuildAndScanImage(ctx context.Context, contextPath, dockerfilePath string) (*BuildResult, error) {
+// BuildOptions configures the SBOM/vulnerability report artifacts that
+// BuildAndScanImage produces in addition to its usual HTML summary.
+type BuildOptions struct {
+	// SBOMFormat is one of "cyclonedx-json" or "spdx-json"; any other
+	// non-empty value is rejected by writeReportArtifacts. Empty skips SBOM
+	// generation.
+	SBOMFormat string
+	// ReportFormat is one of "sarif", "cyclonedx" or "json". Empty skips report generation.
+	ReportFormat string
+	// OutputPath is where the generated report is written. Defaults to the
+	// docktor directory when empty.
+	OutputPath string
+	// FailOn makes BuildAndScanImage return ErrPolicyViolation if any
+	// vulnerability meets or exceeds this severity.
+	FailOn string
+}
+
+// ErrPolicyViolation is returned by BuildAndScanImage when a scan's findings
+// meet or exceed the configured --fail-on severity threshold.
+type ErrPolicyViolation struct {
+	Severity string
+	Count    int
+}
+
+func (e *ErrPolicyViolation) Error() string {
+	return fmt.Sprintf("%d finding(s) at or above %s severity", e.Count, e.Severity)
+}
+
+func (c *Client) BuildAndScanImage(ctx context.Context, contextPath, dockerfilePath string, opts *BuildOptions) (*BuildResult, error) {
 	color.Blue("\n🚀 Starting build and scan process...")
 
 	// Create a unique build ID
@@ -113,12 +158,19 @@ func (c *Client) BuildAndScanImage(ctx context.Context, contextPath, dockerfileP
 		}
 	}
 
-	// Upload build context
-	if err := createAndUploadContext(ctx, c.storageClient, bucketName, buildID, contextPath); err != nil {
+	// Upload build context, keyed by its own content digest so an
+	// unchanged source tree reuses the same Cloud Storage object across
+	// runs instead of re-uploading it.
+	digest, err := createAndUploadContext(ctx, c.storageClient, bucketName, contextPath)
+	if err != nil {
 		return nil, fmt.Errorf("failed to upload build context: %w", err)
 	}
 
-	// Get the Dockerfile path relative to the context
+	// Get the Dockerfile path relative to the context. This has to happen
+	// before the cache lookup below: two builds can share the same context
+	// digest (e.g. a tree containing both Dockerfile.dev and
+	// Dockerfile.prod) while selecting different Dockerfiles to actually
+	// build, so the digest alone isn't enough to key the scan cache.
 	dockerfileArg := "Dockerfile"
 	if dockerfilePath != "" {
 		// Convert to absolute path if it's not already
@@ -136,6 +188,36 @@ func (c *Client) BuildAndScanImage(ctx context.Context, contextPath, dockerfileP
 	}
 	color.Cyan("📄 Using Dockerfile: %s", dockerfileArg)
 
+	// gcp.BuildOptions has no build-args field yet, so there are none to
+	// fold in here; once the gcp backend grows build-arg support this is
+	// the one place that needs to pass them through to scanCacheKey too.
+	cacheKey := scanCacheKey(digest, dockerfileArg, nil)
+
+	// If a previous run already built and scanned this exact context with
+	// this exact Dockerfile, skip the build entirely and reuse its cached
+	// scan results.
+	if cached, ok := c.cachedScanResults(ctx, bucketName, cacheKey); ok {
+		color.Green("♻️  Build context sha256:%s (Dockerfile %s) unchanged, reusing cached scan results", digest[:12], dockerfileArg)
+		if opts != nil {
+			if err := writeReportArtifacts(buildID, cached, opts); err != nil {
+				return nil, fmt.Errorf("failed to write report artifacts: %w", err)
+			}
+			if violation := checkFailOn(cached, opts.FailOn); violation != nil {
+				return nil, violation
+			}
+		}
+		now := time.Now()
+		return &BuildResult{
+			ID:            buildID,
+			Status:        "CACHED",
+			StartTime:     now,
+			EndTime:       now,
+			Logs:          fmt.Sprintf("https://console.cloud.google.com/cloud-build/builds/%s?project=%s", buildID, c.projectID),
+			ScanResults:   cached,
+			ContextDigest: digest,
+		}, nil
+	}
+
 	// Create build request
 	color.Blue("\n🔨 Creating build request...")
 	build := &cloudbuild.Build{
@@ -164,7 +246,7 @@ func (c *Client) BuildAndScanImage(ctx context.Context, contextPath, dockerfileP
 		Source: &cloudbuild.Source{
 			StorageSource: &cloudbuild.StorageSource{
 				Bucket: bucketName,
-				Object: fmt.Sprintf("%s/context.tar.gz", buildID),
+				Object: fmt.Sprintf("sha256/%s.tar.gz", digest),
 			},
 		},
 		Artifacts: &cloudbuild.Artifacts{
@@ -183,61 +265,224 @@ func (c *Client) BuildAndScanImage(ctx context.Context, contextPath, dockerfileP
 	}
 
 	color.Cyan("⏳ Waiting for build to complete...")
-	// Wait for build completion
+	meta, err := c.streamBuildProgress(ctx, operation.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if meta.Build.Status != "" && meta.Build.Status != "SUCCESS" {
+		return nil, fmt.Errorf("cloud build %s finished with status %s", buildID, meta.Build.Status)
+	}
+
+	color.Green("✅ Build completed!")
+
+	// Check if the scan results file exists
+	color.Blue("\n🔍 Retrieving scan results...")
+	scanResults, err := c.getScanResults(ctx, bucketName, buildID)
+	if err != nil {
+		// If we can't get the scan results, the build might have failed
+		return nil, fmt.Errorf("build completed but failed to get scan results: %w", err)
+	}
+
+	// Get build start and end times from the operation metadata tracked
+	// alongside the streamed progress above.
+	startTime := time.Now()
+	finishTime := time.Now()
+	if meta.Build.StartTime != "" {
+		startTime, _ = time.Parse(time.RFC3339, meta.Build.StartTime)
+	}
+	if meta.Build.EndTime != "" {
+		finishTime, _ = time.Parse(time.RFC3339, meta.Build.EndTime)
+	}
+
+	color.Green("✨ Scan completed successfully!")
+	color.Cyan("📊 Found %d vulnerabilities", len(scanResults.Vulnerabilities))
+
+	if err := c.cacheScanResults(ctx, bucketName, cacheKey, scanResults); err != nil {
+		color.Yellow("⚠️  Warning: failed to cache scan results for reuse: %v", err)
+	}
+
+	if opts != nil {
+		if err := writeReportArtifacts(buildID, scanResults, opts); err != nil {
+			return nil, fmt.Errorf("failed to write report artifacts: %w", err)
+		}
+
+		if violation := checkFailOn(scanResults, opts.FailOn); violation != nil {
+			return nil, violation
+		}
+	}
+
+	return &BuildResult{
+		ID:            buildID,
+		Status:        "SUCCESS",
+		StartTime:     startTime,
+		EndTime:       finishTime,
+		Logs:          fmt.Sprintf("https://console.cloud.google.com/cloud-build/builds/%s?project=%s", buildID, c.projectID),
+		ScanResults:   scanResults,
+		ContextDigest: digest,
+	}, nil
+}
+
+// buildMetadata is the subset of the google.devtools.cloudbuild.v1.Build
+// message embedded in a long-running Operation's Metadata field that
+// streamBuildProgress needs: timing, the logs bucket to tail, and each
+// step's current status.
+type buildMetadata struct {
+	Build struct {
+		Id         string `json:"id"`
+		Status     string `json:"status"`
+		LogsBucket string `json:"logsBucket"`
+		StartTime  string `json:"startTime"`
+		EndTime    string `json:"endTime"`
+		Steps      []struct {
+			Name   string `json:"name"`
+			Status string `json:"status"`
+		} `json:"steps"`
+	} `json:"build"`
+}
+
+// streamBuildProgress polls operationName every 5 seconds until it's done,
+// the same way the original implementation did, but instead of waiting in
+// silence it decodes each poll's operation metadata to print step N/M
+// status transitions and tails the build's GCS log object (the same
+// log-<buildID>.txt that `gcloud builds submit --stream-logs` reads) to
+// os.Stderr as new lines are written. On failure it surfaces the last 200
+// tailed log lines before returning, rather than only the generic
+// "failed to get scan results" error from the caller.
+func (c *Client) streamBuildProgress(ctx context.Context, operationName string) (*buildMetadata, error) {
+	var meta buildMetadata
+	var logOffset int64
+	var tail []string
+	stepStatus := make(map[string]string)
+
 	for {
-		op, err := c.buildService.Operations.Get(operation.Name).Do()
+		op, err := c.buildService.Operations.Get(operationName).Do()
 		if err != nil {
 			return nil, fmt.Errorf("failed to get operation status: %w", err)
 		}
 
-		if op.Done {
-			color.Green("✅ Build completed!")
+		if op.Metadata != nil {
+			if err := json.Unmarshal(op.Metadata, &meta); err == nil {
+				for i, step := range meta.Build.Steps {
+					if step.Status == "" || stepStatus[step.Name] == step.Status {
+						continue
+					}
+					stepStatus[step.Name] = step.Status
+					color.Cyan("🔧 Step %d/%d (%s): %s", i+1, len(meta.Build.Steps), step.Name, step.Status)
+				}
 
-			// Check if the scan results file exists
-			color.Blue("\n🔍 Retrieving scan results...")
-			scanResults, err := c.getScanResults(ctx, bucketName, buildID)
-			if err != nil {
-				// If we can't get the scan results, the build might have failed
-				return nil, fmt.Errorf("build completed but failed to get scan results: %w", err)
+				if meta.Build.LogsBucket != "" && meta.Build.Id != "" {
+					lines, newOffset, err := tailBuildLog(ctx, c.storageClient, meta.Build.LogsBucket, meta.Build.Id, logOffset)
+					if err == nil {
+						logOffset = newOffset
+						for _, line := range lines {
+							fmt.Fprintln(os.Stderr, line)
+							tail = append(tail, line)
+						}
+						if len(tail) > 200 {
+							tail = tail[len(tail)-200:]
+						}
+					}
+				}
 			}
+		}
 
-			// Get build start and end times from the operation
-			startTime := time.Now()
-			finishTime := time.Now()
-
-			// Parse the operation metadata to get timing information
-			if op.Metadata != nil {
-				var metadata struct {
-					Build struct {
-						StartTime string `json:"startTime"`
-						EndTime   string `json:"endTime"`
-					} `json:"build"`
-				}
-				if err := json.Unmarshal(op.Metadata, &metadata); err == nil {
-					if metadata.Build.StartTime != "" {
-						startTime, _ = time.Parse(time.RFC3339, metadata.Build.StartTime)
-					}
-					if metadata.Build.EndTime != "" {
-						finishTime, _ = time.Parse(time.RFC3339, metadata.Build.EndTime)
+		if op.Done {
+			if op.Error != nil || (meta.Build.Status != "" && meta.Build.Status != "SUCCESS") {
+				color.Red("❌ Build failed (status: %s)", meta.Build.Status)
+				if len(tail) > 0 {
+					color.Red("📋 Last %d log line(s):", len(tail))
+					for _, line := range tail {
+						fmt.Fprintln(os.Stderr, line)
 					}
 				}
 			}
 
-			color.Green("✨ Scan completed successfully!")
-			color.Cyan("📊 Found %d vulnerabilities", len(scanResults.Vulnerabilities))
+			// op.Error is the long-running operation itself failing (e.g.
+			// quota exhausted, permission denied) rather than a build step
+			// failing inside a successfully-run build; meta.Build.Status may
+			// still be empty or stale in that case, so it has to be
+			// surfaced here rather than left for the caller's status check.
+			if op.Error != nil {
+				return &meta, fmt.Errorf("cloud build operation failed: %s (code %d)", op.Error.Message, op.Error.Code)
+			}
+			return &meta, nil
+		}
 
-			return &BuildResult{
-				ID:          buildID,
-				Status:      "SUCCESS",
-				StartTime:   startTime,
-				EndTime:     finishTime,
-				Logs:        fmt.Sprintf("https://console.cloud.google.com/cloud-build/builds/%s?project=%s", buildID, c.projectID),
-				ScanResults: scanResults,
-			}, nil
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(5 * time.Second):
 		}
+	}
+}
 
-		time.Sleep(5 * time.Second)
+// tailBuildLog reads any bytes appended to Cloud Build's log object for
+// buildID since offset, returning them split into lines along with the new
+// read offset. Cloud Build keeps appending to this object for the lifetime
+// of the build, so callers poll it the same way they poll operation status.
+func tailBuildLog(ctx context.Context, storageClient *storage.Client, logsBucket, buildID string, offset int64) ([]string, int64, error) {
+	obj := storageClient.Bucket(logsBucket).Object(fmt.Sprintf("log-%s.txt", buildID))
+	reader, err := obj.NewRangeReader(ctx, offset, -1)
+	if err != nil {
+		return nil, offset, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, offset, err
+	}
+	if len(data) == 0 {
+		return nil, offset, nil
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	return lines, offset + int64(len(data)), nil
+}
+
+// parseScanResults decodes a raw `trivy image --format json` document into
+// a ScanResults, independent of wherever it was fetched from (Cloud
+// Storage for getScanResults, or a local trivy invocation), so the
+// parsing itself can be exercised without any Cloud Storage access.
+func parseScanResults(content []byte) (*ScanResults, error) {
+	var results struct {
+		Results []struct {
+			Vulnerabilities []struct {
+				VulnerabilityID  string `json:"VulnerabilityID"`
+				PkgName          string `json:"PkgName"`
+				InstalledVersion string `json:"InstalledVersion"`
+				FixedVersion     string `json:"FixedVersion"`
+				Severity         string `json:"Severity"`
+				Title            string `json:"Title"`
+				Description      string `json:"Description"`
+			} `json:"Vulnerabilities"`
+		} `json:"Results"`
+	}
+
+	if err := json.Unmarshal(content, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse scan results: %w", err)
+	}
+
+	scanResults := &ScanResults{
+		Vulnerabilities: make([]Vulnerability, 0),
+	}
+
+	for _, result := range results.Results {
+		for _, vuln := range result.Vulnerabilities {
+			scanResults.Vulnerabilities = append(scanResults.Vulnerabilities, Vulnerability{
+				VulnerabilityID:  vuln.VulnerabilityID,
+				PkgName:          vuln.PkgName,
+				InstalledVersion: vuln.InstalledVersion,
+				FixedVersion:     vuln.FixedVersion,
+				Severity:         vuln.Severity,
+				Title:            vuln.Title,
+				Description:      vuln.Description,
+			})
+		}
 	}
+
+	return scanResults, nil
 }
 
 func (c *Client) getScanResults(ctx context.Context, bucketName, buildID string) (*ScanResults, error) {
@@ -275,42 +520,9 @@ func (c *Client) getScanResults(ctx context.Context, bucketName, buildID string)
 		return nil, fmt.Errorf("failed to save raw JSON file: %w", err)
 	}
 
-	// Parse the scan results
-	var results struct {
-		Results []struct {
-			Vulnerabilities []struct {
-				VulnerabilityID  string `json:"VulnerabilityID"`
-				PkgName         string `json:"PkgName"`
-				InstalledVersion string `json:"InstalledVersion"`
-				FixedVersion    string `json:"FixedVersion"`
-				Severity        string `json:"Severity"`
-				Title           string `json:"Title"`
-				Description     string `json:"Description"`
-			} `json:"Vulnerabilities"`
-		} `json:"Results"`
-	}
-
-	if err := json.Unmarshal(content, &results); err != nil {
-		return nil, fmt.Errorf("failed to parse scan results: %w", err)
-	}
-
-	// Convert to our ScanResults type
-	scanResults := &ScanResults{
-		Vulnerabilities: make([]Vulnerability, 0),
-	}
-
-	for _, result := range results.Results {
-		for _, vuln := range result.Vulnerabilities {
-			scanResults.Vulnerabilities = append(scanResults.Vulnerabilities, Vulnerability{
-				VulnerabilityID:  vuln.VulnerabilityID,
-				PkgName:         vuln.PkgName,
-				InstalledVersion: vuln.InstalledVersion,
-				FixedVersion:    vuln.FixedVersion,
-				Severity:        vuln.Severity,
-				Title:           vuln.Title,
-				Description:     vuln.Description,
-			})
-		}
+	scanResults, err := parseScanResults(content)
+	if err != nil {
+		return nil, err
 	}
 
 	// Group vulnerabilities by severity
@@ -479,19 +691,391 @@ func (c *Client) getScanResults(ctx context.Context, bucketName, buildID string)
 	return scanResults, nil
 }
 
+// toFindings converts parsed scan results into the format-agnostic shape
+// the report package builds SARIF/CycloneDX documents from.
+func toFindings(results *ScanResults) []report.Finding {
+	findings := make([]report.Finding, 0, len(results.Vulnerabilities))
+	for _, vuln := range results.Vulnerabilities {
+		findings = append(findings, report.Finding{
+			ID:               vuln.VulnerabilityID,
+			PkgName:          vuln.PkgName,
+			InstalledVersion: vuln.InstalledVersion,
+			FixedVersion:     vuln.FixedVersion,
+			Severity:         vuln.Severity,
+			Title:            vuln.Title,
+			Description:      vuln.Description,
+		})
+	}
+	return findings
+}
+
+// writeReportArtifacts renders the scan results as a SBOM and/or
+// vulnerability report according to opts and writes them to disk.
+func writeReportArtifacts(buildID string, results *ScanResults, opts *BuildOptions) error {
+	if opts.ReportFormat == "" && opts.SBOMFormat == "" {
+		return nil
+	}
+
+	outputPath := opts.OutputPath
+	if outputPath == "" {
+		outputPath = filepath.Join("docktor", fmt.Sprintf("%s-report", buildID))
+	}
+
+	findings := toFindings(results)
+
+	switch opts.ReportFormat {
+	case "sarif":
+		sarif := report.NewSARIFReport("trivy", findings)
+		data, err := sarif.Marshal()
+		if err != nil {
+			return fmt.Errorf("failed to marshal SARIF report: %w", err)
+		}
+		if err := os.WriteFile(outputPath+".sarif.json", data, 0644); err != nil {
+			return fmt.Errorf("failed to write SARIF report: %w", err)
+		}
+	case "cyclonedx", "":
+		// handled below via SBOMFormat
+	case "json":
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON report: %w", err)
+		}
+		if err := os.WriteFile(outputPath+".json", data, 0644); err != nil {
+			return fmt.Errorf("failed to write JSON report: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported --report-format %q: must be one of sarif, cyclonedx, json", opts.ReportFormat)
+	}
+
+	switch opts.SBOMFormat {
+	case "cyclonedx-json", "spdx-json", "":
+		// handled below
+	default:
+		return fmt.Errorf("unsupported --sbom-format %q: must be one of cyclonedx-json, spdx-json", opts.SBOMFormat)
+	}
+
+	if opts.SBOMFormat == "cyclonedx-json" || opts.ReportFormat == "cyclonedx" {
+		bom := report.NewCycloneDXBOM(findings)
+		data, err := bom.Marshal()
+		if err != nil {
+			return fmt.Errorf("failed to marshal CycloneDX SBOM: %w", err)
+		}
+		if err := os.WriteFile(outputPath+".cdx.json", data, 0644); err != nil {
+			return fmt.Errorf("failed to write CycloneDX SBOM: %w", err)
+		}
+	}
+
+	if opts.SBOMFormat == "spdx-json" {
+		doc := report.NewSPDXDocument(buildID, findings)
+		data, err := doc.Marshal()
+		if err != nil {
+			return fmt.Errorf("failed to marshal SPDX SBOM: %w", err)
+		}
+		if err := os.WriteFile(outputPath+".spdx.json", data, 0644); err != nil {
+			return fmt.Errorf("failed to write SPDX SBOM: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// checkFailOn returns an *ErrPolicyViolation if any finding meets or
+// exceeds failOn severity, or nil if the gate passes (failOn is empty).
+func checkFailOn(results *ScanResults, failOn string) error {
+	if failOn == "" {
+		return nil
+	}
+	failOn = strings.ToUpper(failOn)
+
+	count := 0
+	for _, vuln := range results.Vulnerabilities {
+		if report.SeverityMeetsThreshold(vuln.Severity, failOn) {
+			count++
+		}
+	}
+
+	if count > 0 {
+		return &ErrPolicyViolation{Severity: failOn, Count: count}
+	}
+
+	return nil
+}
+
+// LintIssue is a single Hadolint finding.
+type LintIssue struct {
+	Line    int
+	Message string
+	Level   string
+	Code    string
+}
+
+// LintResult holds every issue Hadolint reported for a Dockerfile.
+type LintResult struct {
+	Issues []LintIssue
+}
+
+// LintDockerfile runs Hadolint against dockerfilePath in Cloud Build and
+// returns its findings. contextPath is used only to resolve dockerfilePath
+// relative to the build context; the Dockerfile itself is the sole input
+// uploaded to Cloud Build.
+func (c *Client) LintDockerfile(ctx context.Context, contextPath, dockerfilePath string) (*LintResult, error) {
+	buildID := fmt.Sprintf("docktor-lint-%s", uuid.New().String())
+
+	bucketName := fmt.Sprintf("%s-docktor-builds", c.projectID)
+	bucket := c.storageClient.Bucket(bucketName)
+	if _, err := bucket.Attrs(ctx); err != nil {
+		if err := bucket.Create(ctx, c.projectID, nil); err != nil {
+			return nil, fmt.Errorf("failed to create bucket: %w", err)
+		}
+	}
+
+	dockerfileContent, err := os.ReadFile(dockerfilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Dockerfile: %w", err)
+	}
+
+	obj := bucket.Object(fmt.Sprintf("%s/Dockerfile", buildID))
+	writer := obj.NewWriter(ctx)
+	if _, err := writer.Write(dockerfileContent); err != nil {
+		return nil, fmt.Errorf("failed to upload Dockerfile: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close Dockerfile upload: %w", err)
+	}
+
+	build := &cloudbuild.Build{
+		Steps: []*cloudbuild.BuildStep{
+			{
+				Name:       "hadolint/hadolint:latest-debian",
+				Entrypoint: "sh",
+				Args:       []string{"-c", "hadolint --format json Dockerfile > /workspace/lint-results.json || true"},
+			},
+		},
+		Timeout: "300s",
+		Source: &cloudbuild.Source{
+			StorageSource: &cloudbuild.StorageSource{
+				Bucket: bucketName,
+				Object: fmt.Sprintf("%s/Dockerfile", buildID),
+			},
+		},
+		Artifacts: &cloudbuild.Artifacts{
+			Objects: &cloudbuild.ArtifactObjects{
+				Location: fmt.Sprintf("gs://%s/%s", bucketName, buildID),
+				Paths:    []string{"lint-results.json"},
+			},
+		},
+	}
+
+	operation, err := c.buildService.Projects.Builds.Create(c.projectID, build).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start lint build: %w", err)
+	}
+
+	for {
+		op, err := c.buildService.Operations.Get(operation.Name).Do()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get lint operation status: %w", err)
+		}
+
+		if op.Done {
+			break
+		}
+
+		time.Sleep(5 * time.Second)
+	}
+
+	resultsObj := bucket.Object(fmt.Sprintf("%s/lint-results.json", buildID))
+	reader, err := resultsObj.NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lint results: %w", err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lint results content: %w", err)
+	}
+
+	var hadolintIssues []struct {
+		Line    int    `json:"line"`
+		Message string `json:"message"`
+		Level   string `json:"level"`
+		Code    string `json:"code"`
+	}
+	if err := json.Unmarshal(content, &hadolintIssues); err != nil {
+		return nil, fmt.Errorf("failed to parse lint results: %w", err)
+	}
+
+	result := &LintResult{Issues: make([]LintIssue, 0, len(hadolintIssues))}
+	for _, issue := range hadolintIssues {
+		result.Issues = append(result.Issues, LintIssue{
+			Line:    issue.Line,
+			Message: issue.Message,
+			Level:   issue.Level,
+			Code:    issue.Code,
+		})
+	}
+
+	return result, nil
+}
+
+// Cleanup deletes the per-build scan-results artifact Cloud Build wrote to
+// bucketName/buildID/. It no longer deletes the build context archive:
+// createAndUploadContext now stores that at a content-addressed
+// sha256/<digest>.tar.gz path and deliberately keeps it around so a later
+// build with an unchanged source tree can skip both the upload and the
+// build itself (see BuildAndScanImage's cachedScanResults check). Use
+// PruneContextCache to reclaim old content-addressed objects instead.
 func (c *Client) Cleanup(ctx context.Context, buildID string) error {
-	// Delete the build context from Cloud Storage
 	bucketName := fmt.Sprintf("%s-docktor-builds", c.projectID)
 	bucket := c.storageClient.Bucket(bucketName)
-	object := bucket.Object(fmt.Sprintf("%s/context.tar.gz", buildID))
-	
-	if err := object.Delete(ctx); err != nil {
-		return fmt.Errorf("failed to delete build context: %w", err)
+	object := bucket.Object(fmt.Sprintf("%s/scan-results.json", buildID))
+
+	if err := object.Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("failed to delete scan results artifact: %w", err)
+	}
+
+	return nil
+}
+
+// PruneContextCache garbage-collects the content-addressed build context
+// archives (and their companion cached scan results) that
+// createAndUploadContext and cacheScanResults write under sha256/. Objects
+// older than maxAgeDays are deleted first; if the cache is still over
+// maxBytes afterward, the least-recently-updated remaining objects are
+// deleted until it's back under budget. A non-positive maxAgeDays or
+// maxBytes disables that half of the sweep.
+func (c *Client) PruneContextCache(ctx context.Context, maxAgeDays int, maxBytes int64) error {
+	bucketName := fmt.Sprintf("%s-docktor-builds", c.projectID)
+	bucket := c.storageClient.Bucket(bucketName)
+
+	it := bucket.Objects(ctx, &storage.Query{Prefix: "sha256/"})
+	var objects []*storage.ObjectAttrs
+	var totalBytes int64
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list cached build contexts: %w", err)
+		}
+		objects = append(objects, attrs)
+		totalBytes += attrs.Size
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Updated.Before(objects[j].Updated) })
+
+	remaining := objects[:0]
+	if maxAgeDays > 0 {
+		cutoff := time.Now().Add(-time.Duration(maxAgeDays) * 24 * time.Hour)
+		for _, attrs := range objects {
+			if attrs.Updated.Before(cutoff) {
+				if err := bucket.Object(attrs.Name).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+					return fmt.Errorf("failed to prune %s: %w", attrs.Name, err)
+				}
+				totalBytes -= attrs.Size
+				color.Yellow("🗑️  Pruned stale context cache object: %s", attrs.Name)
+				continue
+			}
+			remaining = append(remaining, attrs)
+		}
+	} else {
+		remaining = objects
+	}
+
+	if maxBytes > 0 {
+		for _, attrs := range remaining {
+			if totalBytes <= maxBytes {
+				break
+			}
+			if err := bucket.Object(attrs.Name).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+				return fmt.Errorf("failed to prune %s: %w", attrs.Name, err)
+			}
+			totalBytes -= attrs.Size
+			color.Yellow("🗑️  Pruned context cache object to stay under size budget: %s", attrs.Name)
+		}
 	}
 
 	return nil
 }
 
+// scanCacheKey derives the key scan results are cached under from
+// everything that can change what actually gets built and scanned: the
+// context digest, the Dockerfile selected out of that context, and any
+// build args. Two builds from the same content digest that pick different
+// Dockerfiles (or, once gcp.BuildOptions grows build-arg support, different
+// build args) must never share a cached scan result, since they can
+// produce completely different images.
+func scanCacheKey(contextDigest, dockerfileArg string, buildArgs map[string]string) string {
+	keys := make([]string, 0, len(buildArgs))
+	for k := range buildArgs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(contextDigest)
+	b.WriteString("\x00")
+	b.WriteString(dockerfileArg)
+	for _, k := range keys {
+		b.WriteString("\x00")
+		b.WriteString(k)
+		b.WriteString("=")
+		b.WriteString(buildArgs[k])
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// cachedScanResults loads the companion sha256/<key>.scan.json object for a
+// previously uploaded build context, if one exists. It returns ok=false
+// (never an error) for a cache miss, since "no cached result yet" is the
+// expected common case, not a failure.
+func (c *Client) cachedScanResults(ctx context.Context, bucketName, key string) (*ScanResults, bool) {
+	if key == "" {
+		return nil, false
+	}
+
+	reader, err := c.storageClient.Bucket(bucketName).Object(fmt.Sprintf("sha256/%s.scan.json", key)).NewReader(ctx)
+	if err != nil {
+		return nil, false
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, false
+	}
+
+	var results ScanResults
+	if err := json.Unmarshal(content, &results); err != nil {
+		return nil, false
+	}
+	return &results, true
+}
+
+// cacheScanResults writes results to the sha256/<key>.scan.json object, so
+// a future build that derives the same key from cachedScanResults can skip
+// straight to it.
+func (c *Client) cacheScanResults(ctx context.Context, bucketName, key string, results *ScanResults) error {
+	if key == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		return fmt.Errorf("failed to marshal scan results for caching: %w", err)
+	}
+
+	writer := c.storageClient.Bucket(bucketName).Object(fmt.Sprintf("sha256/%s.scan.json", key)).NewWriter(ctx)
+	if _, err := writer.Write(data); err != nil {
+		return fmt.Errorf("failed to write cached scan results: %w", err)
+	}
+	return writer.Close()
+}
+
 func (c *Client) StartBuild(ctx context.Context, buildContext, dockerfilePath string) (*BuildResult, error) {
 	// Create a unique build ID
 	buildID := fmt.Sprintf("docktor-%s", uuid.New().String())
@@ -543,39 +1127,68 @@ func (c *Client) StartBuild(ctx context.Context, buildContext, dockerfilePath st
 	}
 }
 
-func createAndUploadContext(ctx context.Context, storageClient *storage.Client, bucketName, buildID, contextPath string) error {
+// createAndUploadContext tars and gzips contextPath into a temporary file,
+// hashing the compressed bytes as they're written (one pass, not a second
+// read-back), and uploads it to bucketName at sha256/<digest>.tar.gz. If
+// that object already exists — another build already uploaded this exact
+// context — the upload is skipped and the existing object is reused. It
+// returns the archive's digest so the caller can point the build's
+// StorageSource at it and key a scan-results cache off it.
+func createAndUploadContext(ctx context.Context, storageClient *storage.Client, bucketName, contextPath string) (string, error) {
 	color.Blue("📦 Preparing build context...")
 
 	// Create a temporary file for the tar archive
 	tmpFile, err := os.CreateTemp("", "docktor-context-*.tar.gz")
 	if err != nil {
-		return fmt.Errorf("failed to create temporary file: %w", err)
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
 	}
 	defer os.Remove(tmpFile.Name())
 	defer tmpFile.Close()
 
 	color.Cyan("📝 Creating archive...")
 
+	// Hash the compressed stream as it's written so the digest is ready
+	// the moment the archive is, instead of re-reading the file afterward.
+	hasher := sha256.New()
+
 	// Create gzip writer
-	gzipWriter := gzip.NewWriter(tmpFile)
+	gzipWriter := gzip.NewWriter(io.MultiWriter(tmpFile, hasher))
 	defer gzipWriter.Close()
 
 	// Create tar writer
 	tarWriter := tar.NewWriter(gzipWriter)
 	defer tarWriter.Close()
 
-	// Load all .gitignore files
-	color.Cyan("🔍 Loading .gitignore patterns...")
-	gitignore, err := loadGitignore(contextPath)
+	// Load .dockerignore, matching Docker's own semantics (pattern order
+	// preserved, "!" negations, "**" globs). .gitignore is only consulted
+	// as a fallback when no .dockerignore is present, since it's not what
+	// Docker itself reads.
+	color.Cyan("🔍 Loading .dockerignore patterns...")
+	dockerignore, err := loadDockerignore(contextPath)
 	if err != nil {
-		return fmt.Errorf("failed to load .gitignore patterns: %w", err)
+		return "", fmt.Errorf("failed to load .dockerignore: %w", err)
+	}
+
+	var gitignore *ignore.GitIgnore
+	if dockerignore == nil {
+		color.Cyan("🔍 No .dockerignore found, falling back to .gitignore patterns...")
+		gitignore, err = loadGitignore(contextPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to load .gitignore patterns: %w", err)
+		}
 	}
 
 	// Function to check if a path should be excluded
 	shouldExclude := func(path string) bool {
+		// Docker always includes the Dockerfile and .dockerignore, even
+		// if a pattern would otherwise exclude them.
+		if path == "Dockerfile" || path == ".dockerignore" {
+			return false
+		}
+
 		// Get the base name of the path
 		base := filepath.Base(path)
-		
+
 		// Exclude node_modules directories
 		if strings.Contains(path, "/node_modules/") || strings.HasSuffix(path, "/node_modules") {
 			return true
@@ -591,9 +1204,11 @@ func createAndUploadContext(ctx context.Context, storageClient *storage.Client,
 			return true
 		}
 
-		// Check against .gitignore patterns
-		if gitignore != nil && gitignore.MatchesPath(path) {
-			return true
+		if dockerignore != nil {
+			return dockerignore.MatchesPath(path)
+		}
+		if gitignore != nil {
+			return gitignore.MatchesPath(path)
 		}
 
 		return false
@@ -637,6 +1252,7 @@ func createAndUploadContext(ctx context.Context, storageClient *storage.Client,
 			return fmt.Errorf("failed to create tar header: %w", err)
 		}
 		header.Name = relPath
+		normalizeHeader(header, info)
 
 		// Write header
 		if err := tarWriter.WriteHeader(header); err != nil {
@@ -668,15 +1284,15 @@ func createAndUploadContext(ctx context.Context, storageClient *storage.Client,
 	})
 
 	if err != nil {
-		return fmt.Errorf("failed to create tar archive: %w", err)
+		return "", fmt.Errorf("failed to create tar archive: %w", err)
 	}
 
 	// Close writers to ensure all data is written
 	if err := tarWriter.Close(); err != nil {
-		return fmt.Errorf("failed to close tar writer: %w", err)
+		return "", fmt.Errorf("failed to close tar writer: %w", err)
 	}
 	if err := gzipWriter.Close(); err != nil {
-		return fmt.Errorf("failed to close gzip writer: %w", err)
+		return "", fmt.Errorf("failed to close gzip writer: %w", err)
 	}
 
 	// Print statistics
@@ -685,29 +1301,94 @@ func createAndUploadContext(ctx context.Context, storageClient *storage.Client,
 	color.Yellow("  Files excluded: %d (%s)", excludedFiles, formatSize(excludedSize))
 	color.Green("  Final archive size: %s", formatSize(getFileSize(tmpFile)))
 
+	digest := hex.EncodeToString(hasher.Sum(nil))
+
 	// Reset file pointer to beginning
 	if _, err := tmpFile.Seek(0, 0); err != nil {
-		return fmt.Errorf("failed to reset file pointer: %w", err)
+		return "", fmt.Errorf("failed to reset file pointer: %w", err)
 	}
 
-	color.Blue("\n☁️  Uploading to Cloud Storage...")
+	objectName := fmt.Sprintf("sha256/%s.tar.gz", digest)
+	color.Blue("\n☁️  Uploading build context to gs://%s/%s...", bucketName, objectName)
 
-	// Upload to Cloud Storage
+	// Upload to Cloud Storage, but only if no object already sits at this
+	// digest: the precondition makes the existence check and the upload a
+	// single atomic call instead of a check-then-act race.
 	bucket := storageClient.Bucket(bucketName)
-	obj := bucket.Object(fmt.Sprintf("%s/context.tar.gz", buildID))
+	obj := bucket.Object(objectName).If(storage.Conditions{DoesNotExist: true})
 	writer := obj.NewWriter(ctx)
 
 	if _, err := io.Copy(writer, tmpFile); err != nil {
-		return fmt.Errorf("failed to upload to Cloud Storage: %w", err)
+		return "", fmt.Errorf("failed to upload to Cloud Storage: %w", err)
 	}
 
 	if err := writer.Close(); err != nil {
-		return fmt.Errorf("failed to close Cloud Storage writer: %w", err)
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+			color.Green("♻️  Build context sha256:%s already cached, skipping upload", digest[:12])
+			return digest, nil
+		}
+		return "", fmt.Errorf("failed to close Cloud Storage writer: %w", err)
 	}
 
-	color.Green("✅ Build context uploaded successfully!")
+	color.Green("✅ Build context uploaded successfully! (sha256:%s)", digest[:12])
 
-	return nil
+	return digest, nil
+}
+
+// epoch is the fixed modification time written into every tar header so
+// that identical file contents always produce an identical archive, no
+// matter when or where they were checked out (see normalizeHeader).
+var epoch = time.Unix(0, 0).UTC()
+
+// normalizeHeader strips non-reproducible metadata (uid/gid, mtime, and
+// the name/group fields) from a tar header, keeping only the file mode.
+// Without this, the same source tree hashes to a different digest on
+// every checkout, since mtimes (and uid/gid in CI) are never identical
+// across runs — defeating createAndUploadContext's whole point of
+// skipping re-upload and re-build for an unchanged tree.
+func normalizeHeader(header *tar.Header, info os.FileInfo) {
+	header.Uid = 0
+	header.Gid = 0
+	header.Uname = ""
+	header.Gname = ""
+	header.ModTime = epoch
+	header.AccessTime = time.Time{}
+	header.ChangeTime = time.Time{}
+
+	if info.IsDir() {
+		header.Mode = 0755
+	} else {
+		header.Mode = 0644
+	}
+}
+
+// loadDockerignore reads <root>/.dockerignore, if present, preserving
+// pattern order so later "!" negations can override earlier matches, the
+// same way `docker build` itself interprets the file. Returns (nil, nil)
+// if no .dockerignore exists, so callers can fall back to .gitignore.
+func loadDockerignore(root string) (*ignore.GitIgnore, error) {
+	path := filepath.Join(root, ".dockerignore")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read .dockerignore: %w", err)
+	}
+
+	lines := strings.Split(string(content), "\n")
+	patterns := make([]string, 0, len(lines))
+	for _, line := range lines {
+		l := strings.TrimSpace(line)
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		patterns = append(patterns, l)
+	}
+
+	color.Green("  📋 Loaded %d .dockerignore patterns", len(patterns))
+	return ignore.CompileIgnoreLines(patterns...), nil
 }
 
 // loadGitignore loads all .gitignore files in the project
@@ -0,0 +1,82 @@
+package gcp
+
+import "testing"
+
+// TestParseScanResults proves parseScanResults flattens a `trivy image
+// --format json` document's per-target Vulnerabilities lists into one
+// ScanResults, independent of where the bytes came from (Cloud Storage or a
+// local trivy invocation).
+func TestParseScanResults(t *testing.T) {
+	raw := []byte(`{
+		"Results": [
+			{
+				"Target": "app (debian 12)",
+				"Vulnerabilities": [
+					{
+						"VulnerabilityID": "CVE-2024-0001",
+						"PkgName": "libfoo",
+						"InstalledVersion": "1.2.3",
+						"FixedVersion": "1.2.4",
+						"Severity": "HIGH",
+						"Title": "libfoo buffer overflow",
+						"Description": "A crafted input overflows a buffer."
+					}
+				]
+			},
+			{
+				"Target": "app/go.sum",
+				"Vulnerabilities": [
+					{
+						"VulnerabilityID": "CVE-2024-0002",
+						"PkgName": "github.com/example/bar",
+						"InstalledVersion": "v0.1.0",
+						"FixedVersion": "",
+						"Severity": "CRITICAL",
+						"Title": "bar remote code execution",
+						"Description": "Unvalidated input reaches an eval."
+					}
+				]
+			}
+		]
+	}`)
+
+	results, err := parseScanResults(raw)
+	if err != nil {
+		t.Fatalf("parseScanResults returned error: %v", err)
+	}
+
+	if len(results.Vulnerabilities) != 2 {
+		t.Fatalf("expected 2 vulnerabilities, got %d: %+v", len(results.Vulnerabilities), results.Vulnerabilities)
+	}
+
+	first := results.Vulnerabilities[0]
+	if first.VulnerabilityID != "CVE-2024-0001" || first.PkgName != "libfoo" || first.FixedVersion != "1.2.4" {
+		t.Errorf("unexpected first vulnerability: %+v", first)
+	}
+
+	second := results.Vulnerabilities[1]
+	if second.VulnerabilityID != "CVE-2024-0002" || second.Severity != "CRITICAL" || second.FixedVersion != "" {
+		t.Errorf("unexpected second vulnerability: %+v", second)
+	}
+}
+
+// TestParseScanResultsNoVulnerabilities proves a target with no findings (or
+// no targets at all) yields an empty, non-nil Vulnerabilities slice rather
+// than an error.
+func TestParseScanResultsNoVulnerabilities(t *testing.T) {
+	results, err := parseScanResults([]byte(`{"Results": [{"Target": "app (debian 12)"}]}`))
+	if err != nil {
+		t.Fatalf("parseScanResults returned error: %v", err)
+	}
+	if results.Vulnerabilities == nil || len(results.Vulnerabilities) != 0 {
+		t.Errorf("expected empty non-nil slice, got %#v", results.Vulnerabilities)
+	}
+}
+
+// TestParseScanResultsInvalidJSON proves malformed input is reported as an
+// error instead of silently producing an empty result.
+func TestParseScanResultsInvalidJSON(t *testing.T) {
+	if _, err := parseScanResults([]byte(`not json`)); err == nil {
+		t.Error("expected an error for invalid JSON, got nil")
+	}
+}
@@ -0,0 +1,88 @@
+// Package logging provides docktor's structured logger and a redaction
+// layer that keeps secrets (Fly.io API tokens, GCP service account keys)
+// out of anything written through it.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// New builds a slog.Logger writing to stderr in the given format
+// ("text" or "json") at the given level ("debug", "info", "warn", "error").
+func New(format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if level == "" {
+		level = "info"
+	}
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	writer := NewRedactingWriter(os.Stderr)
+
+	var handler slog.Handler
+	switch format {
+	case "", "text":
+		handler = slog.NewTextHandler(writer, opts)
+	case "json":
+		handler = slog.NewJSONHandler(writer, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q: must be \"text\" or \"json\"", format)
+	}
+
+	return slog.New(handler), nil
+}
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+)
+
+// RegisterSecret marks value (e.g. a FLY_API_TOKEN or the contents of a
+// GCP service account key file) to be masked out of anything written
+// through a RedactingWriter. Empty values are ignored.
+func RegisterSecret(value string) {
+	if value == "" {
+		return
+	}
+
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	secrets = append(secrets, value)
+}
+
+// redactingWriter masks every registered secret out of bytes passed
+// through it before forwarding them to the underlying writer.
+type redactingWriter struct {
+	w io.Writer
+}
+
+// NewRedactingWriter wraps w so that any value previously passed to
+// RegisterSecret is replaced with "***REDACTED***" before being written.
+func NewRedactingWriter(w io.Writer) io.Writer {
+	return &redactingWriter{w: w}
+}
+
+func (r *redactingWriter) Write(p []byte) (int, error) {
+	s := string(p)
+
+	secretsMu.RLock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, "***REDACTED***")
+	}
+	secretsMu.RUnlock()
+
+	if _, err := r.w.Write([]byte(s)); err != nil {
+		return 0, err
+	}
+
+	// Report the original length so callers see a normal io.Writer
+	// contract even though the redacted payload may differ in size.
+	return len(p), nil
+}
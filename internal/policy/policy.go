@@ -0,0 +1,179 @@
+// Package policy implements a vulnerability gating policy: a maximum
+// allowed finding count per severity, evaluated against a
+// scanner.ScanReport after applying CVE ignore rules and a package
+// allowlist.
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/nmsde/docktor/internal/scanner"
+)
+
+// Policy declares how many findings of each severity are tolerated before
+// a scan is considered a failure, plus exceptions to that count.
+type Policy struct {
+	// MaxSeverity maps a severity name (critical, high, medium, low) to
+	// the maximum number of findings allowed at that severity.
+	// Severities with no entry are not gated at all.
+	MaxSeverity map[string]int `mapstructure:"max_severity" yaml:"max_severity,omitempty"`
+	// Ignore excludes matching vulnerabilities from gating entirely.
+	Ignore []IgnoreRule `mapstructure:"ignore" yaml:"ignore,omitempty"`
+	// AllowPackages exempts every finding in these packages from gating,
+	// regardless of severity.
+	AllowPackages []string `mapstructure:"allow_packages" yaml:"allow_packages,omitempty"`
+}
+
+// Enabled reports whether p declares any severity thresholds to gate on.
+func (p Policy) Enabled() bool {
+	return len(p.MaxSeverity) > 0
+}
+
+// IgnoreRule excludes vulnerabilities from policy gating. ID may be an
+// exact CVE ID or a regex pattern (e.g. "CVE-2023-.*"), à la
+// .trivyignore. Package and Version optionally scope the rule to one
+// package, and, if Version is set, one installed version of it.
+// ExpiresAt, if set (as YYYY-MM-DD), limits how long the rule applies -
+// once passed, the rule stops suppressing matches and is instead
+// reported as an expired-ignore warning.
+type IgnoreRule struct {
+	ID        string `mapstructure:"id" yaml:"id"`
+	Package   string `mapstructure:"package" yaml:"package,omitempty"`
+	Version   string `mapstructure:"version" yaml:"version,omitempty"`
+	ExpiresAt string `mapstructure:"expires_at" yaml:"expires_at,omitempty"`
+	Reason    string `mapstructure:"reason" yaml:"reason,omitempty"`
+}
+
+// expired reports whether r's ExpiresAt has passed as of now. A rule with
+// no ExpiresAt, or one whose date fails to parse, never expires.
+func (r IgnoreRule) expired(now time.Time) bool {
+	if r.ExpiresAt == "" {
+		return false
+	}
+	expiry, err := time.Parse("2006-01-02", r.ExpiresAt)
+	if err != nil {
+		return false
+	}
+	return now.After(expiry)
+}
+
+// matches reports whether r applies to v, honoring its package/version
+// scoping and treating ID as a regex when it compiles as one, falling
+// back to an exact match otherwise.
+func (r IgnoreRule) matches(v scanner.Vulnerability) bool {
+	if r.Package != "" && r.Package != v.Package {
+		return false
+	}
+	if r.Version != "" && r.Version != v.Version {
+		return false
+	}
+
+	if re, err := regexp.Compile("^(" + r.ID + ")$"); err == nil {
+		return re.MatchString(v.ID)
+	}
+	return r.ID == v.ID
+}
+
+// RuleResult is the per-severity breakdown of a PolicyResult.
+type RuleResult struct {
+	Severity string
+	Max      int
+	Count    int
+	Pass     bool
+}
+
+// PolicyResult is the outcome of evaluating a Policy against a
+// scanner.ScanReport.
+type PolicyResult struct {
+	Pass    bool
+	Rules   []RuleResult
+	Expired []IgnoreRule
+}
+
+// Evaluate gates report's vulnerabilities against p: matching ignore
+// rules and allowlisted packages are excluded first, then the remaining
+// findings are counted per severity and compared against p.MaxSeverity.
+func (p Policy) Evaluate(report *scanner.ScanReport) *PolicyResult {
+	now := time.Now()
+	result := &PolicyResult{Pass: true}
+
+	allowed := make(map[string]bool, len(p.AllowPackages))
+	for _, pkg := range p.AllowPackages {
+		allowed[pkg] = true
+	}
+
+	seenExpired := make(map[string]bool)
+	counts := make(map[string]int)
+	for _, v := range report.Vulnerabilities {
+		if allowed[v.Package] {
+			continue
+		}
+
+		if rule, ok := p.matchingIgnoreRule(v, now); ok {
+			continue
+		} else if rule != nil && !seenExpired[rule.ID] {
+			result.Expired = append(result.Expired, *rule)
+			seenExpired[rule.ID] = true
+		}
+
+		counts[strings.ToLower(string(v.Severity))]++
+	}
+
+	for severity, max := range p.MaxSeverity {
+		count := counts[strings.ToLower(severity)]
+		rule := RuleResult{Severity: severity, Max: max, Count: count, Pass: count <= max}
+		if !rule.Pass {
+			result.Pass = false
+		}
+		result.Rules = append(result.Rules, rule)
+	}
+
+	return result
+}
+
+// matchingIgnoreRule returns the first ignore rule matching v. If that
+// match is still active, ok is true and the vulnerability should be
+// skipped. If the only match has expired, ok is false but the expired
+// rule is returned so the caller can surface a warning.
+func (p Policy) matchingIgnoreRule(v scanner.Vulnerability, now time.Time) (rule *IgnoreRule, ok bool) {
+	for i := range p.Ignore {
+		r := p.Ignore[i]
+		if !r.matches(v) {
+			continue
+		}
+		if r.expired(now) {
+			rule = &r
+			continue
+		}
+		return &r, true
+	}
+	return rule, false
+}
+
+// PrintSummary prints the policy's pass/fail verdict, a per-severity rule
+// breakdown, and a warning for every ignore rule that has expired and so
+// is no longer suppressing its matches.
+func (pr *PolicyResult) PrintSummary() {
+	fmt.Println("\nPolicy Summary:")
+
+	for _, rule := range pr.Rules {
+		status := "PASS"
+		if !rule.Pass {
+			status = "FAIL"
+		}
+		fmt.Printf("  %s: %d/%d allowed [%s]\n", rule.Severity, rule.Count, rule.Max, status)
+	}
+
+	for _, exp := range pr.Expired {
+		fmt.Printf("  WARNING: ignore rule %q expired on %s and is no longer suppressing matches\n", exp.ID, exp.ExpiresAt)
+	}
+
+	if pr.Pass {
+		fmt.Println("Result: PASS")
+	} else {
+		fmt.Println("Result: FAIL")
+	}
+}
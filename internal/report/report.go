@@ -0,0 +1,391 @@
+// Package report provides typed representations of the machine-readable
+// report formats Docktor can emit from a scan: SARIF (for code scanning
+// tools like GitHub) and CycloneDX/SPDX (for SBOM consumers).
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Finding is the minimal, format-agnostic shape of a vulnerability that the
+// SARIF and CycloneDX builders consume. Callers translate their own
+// scan-result types into a slice of Finding before building a report.
+type Finding struct {
+	ID               string
+	PkgName          string
+	InstalledVersion string
+	FixedVersion     string
+	Severity         string
+	Title            string
+	Description      string
+	ImageName        string
+}
+
+// SARIFReport is a minimal SARIF 2.1.0 log containing a single run.
+type SARIFReport struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []SARIFRun  `json:"runs"`
+}
+
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+type SARIFDriver struct {
+	Name            string      `json:"name"`
+	InformationURI  string      `json:"informationUri"`
+	Rules           []SARIFRule `json:"rules"`
+}
+
+type SARIFRule struct {
+	ID               string              `json:"id"`
+	ShortDescription SARIFMultiformat    `json:"shortDescription"`
+	FullDescription  SARIFMultiformat    `json:"fullDescription"`
+}
+
+type SARIFMultiformat struct {
+	Text string `json:"text"`
+}
+
+type SARIFResult struct {
+	RuleID    string            `json:"ruleId"`
+	Level     string            `json:"level"`
+	Message   SARIFMultiformat  `json:"message"`
+	Locations []SARIFLocation   `json:"locations"`
+	Fixes     []SARIFFix        `json:"fixes,omitempty"`
+}
+
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+}
+
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type SARIFFix struct {
+	Description SARIFMultiformat `json:"description"`
+}
+
+// severityToSARIFLevel maps a Trivy-style severity to a SARIF result level.
+func severityToSARIFLevel(severity string) string {
+	switch severity {
+	case "CRITICAL", "HIGH":
+		return "error"
+	case "MEDIUM":
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// NewSARIFReport builds a SARIF 2.1.0 log from a set of findings.
+func NewSARIFReport(toolName string, findings []Finding) *SARIFReport {
+	rules := make([]SARIFRule, 0, len(findings))
+	results := make([]SARIFResult, 0, len(findings))
+
+	for _, f := range findings {
+		rules = append(rules, SARIFRule{
+			ID:               f.ID,
+			ShortDescription: SARIFMultiformat{Text: f.Title},
+			FullDescription:  SARIFMultiformat{Text: f.Description},
+		})
+
+		var fixes []SARIFFix
+		if f.FixedVersion != "" {
+			fixes = []SARIFFix{{Description: SARIFMultiformat{Text: fmt.Sprintf("Upgrade %s to %s", f.PkgName, f.FixedVersion)}}}
+		}
+
+		results = append(results, SARIFResult{
+			RuleID:  f.ID,
+			Level:   severityToSARIFLevel(f.Severity),
+			Message: SARIFMultiformat{Text: f.Title},
+			Locations: []SARIFLocation{
+				{PhysicalLocation: SARIFPhysicalLocation{ArtifactLocation: SARIFArtifactLocation{
+					URI: fmt.Sprintf("pkg:%s@%s", f.PkgName, f.InstalledVersion),
+				}}},
+			},
+			Fixes: fixes,
+		})
+	}
+
+	return &SARIFReport{
+		Schema:  "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []SARIFRun{
+			{
+				Tool: SARIFTool{Driver: SARIFDriver{
+					Name:           toolName,
+					InformationURI: "https://github.com/nmsde/docktor",
+					Rules:          rules,
+				}},
+				Results: results,
+			},
+		},
+	}
+}
+
+// Marshal renders the report as indented JSON.
+func (r *SARIFReport) Marshal() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// CycloneDXBOM is a minimal CycloneDX 1.5 BOM with components and the
+// vulnerabilities that affect them.
+type CycloneDXBOM struct {
+	BOMFormat       string                  `json:"bomFormat"`
+	SpecVersion     string                  `json:"specVersion"`
+	Version         int                     `json:"version"`
+	Components      []CycloneDXComponent    `json:"components"`
+	Vulnerabilities []CycloneDXVulnerability `json:"vulnerabilities,omitempty"`
+}
+
+type CycloneDXComponent struct {
+	BOMRef  string `json:"bom-ref"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	PURL    string `json:"purl"`
+}
+
+type CycloneDXVulnerability struct {
+	ID          string              `json:"id"`
+	Description string              `json:"description"`
+	Affects     []CycloneDXAffects  `json:"affects"`
+	Ratings     []CycloneDXRating   `json:"ratings"`
+	Advisories  []CycloneDXAdvisory `json:"advisories,omitempty"`
+}
+
+type CycloneDXAffects struct {
+	Ref string `json:"ref"`
+}
+
+type CycloneDXRating struct {
+	Severity string `json:"severity"`
+}
+
+type CycloneDXAdvisory struct {
+	URL string `json:"url"`
+}
+
+// severityToCycloneDXRating maps a Trivy-style severity to CycloneDX's
+// lowercase rating.severity enum.
+func severityToCycloneDXRating(severity string) string {
+	switch severity {
+	case "CRITICAL":
+		return "critical"
+	case "HIGH":
+		return "high"
+	case "MEDIUM":
+		return "medium"
+	case "LOW":
+		return "low"
+	default:
+		return "unknown"
+	}
+}
+
+// advisoryURL builds a link to the upstream advisory for a vulnerability
+// ID, for the well-known CVE and GHSA identifier schemes. Returns "" for
+// anything else, since we can't guess the URL scheme.
+func advisoryURL(vulnID string) string {
+	switch {
+	case strings.HasPrefix(vulnID, "CVE-"):
+		return "https://nvd.nist.gov/vuln/detail/" + vulnID
+	case strings.HasPrefix(vulnID, "GHSA-"):
+		return "https://github.com/advisories/" + vulnID
+	default:
+		return ""
+	}
+}
+
+// NewCycloneDXBOM builds a CycloneDX 1.5 BOM from a set of findings, one
+// component per distinct package@version.
+func NewCycloneDXBOM(findings []Finding) *CycloneDXBOM {
+	bom := &CycloneDXBOM{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.5",
+		Version:     1,
+	}
+
+	seen := make(map[string]bool)
+	for _, f := range findings {
+		ref := fmt.Sprintf("%s@%s", f.PkgName, f.InstalledVersion)
+		if !seen[ref] {
+			seen[ref] = true
+			bom.Components = append(bom.Components, CycloneDXComponent{
+				BOMRef:  ref,
+				Type:    "library",
+				Name:    f.PkgName,
+				Version: f.InstalledVersion,
+				PURL:    fmt.Sprintf("pkg:generic/%s@%s", f.PkgName, f.InstalledVersion),
+			})
+		}
+
+		var advisories []CycloneDXAdvisory
+		if url := advisoryURL(f.ID); url != "" {
+			advisories = []CycloneDXAdvisory{{URL: url}}
+		}
+
+		bom.Vulnerabilities = append(bom.Vulnerabilities, CycloneDXVulnerability{
+			ID:          f.ID,
+			Description: f.Description,
+			Affects:     []CycloneDXAffects{{Ref: ref}},
+			Ratings:     []CycloneDXRating{{Severity: severityToCycloneDXRating(f.Severity)}},
+			Advisories:  advisories,
+		})
+	}
+
+	return bom
+}
+
+// Marshal renders the BOM as indented JSON.
+func (b *CycloneDXBOM) Marshal() ([]byte, error) {
+	return json.MarshalIndent(b, "", "  ")
+}
+
+// SPDXDocument is a minimal SPDX 2.3 JSON document with one package per
+// distinct package@version and a DESCRIBES relationship from the document
+// to each.
+type SPDXDocument struct {
+	SPDXVersion       string              `json:"spdxVersion"`
+	DataLicense       string              `json:"dataLicense"`
+	SPDXID            string              `json:"SPDXID"`
+	Name              string              `json:"name"`
+	DocumentNamespace string              `json:"documentNamespace"`
+	CreationInfo      SPDXCreationInfo    `json:"creationInfo"`
+	Packages          []SPDXPackage       `json:"packages"`
+	Relationships     []SPDXRelationship  `json:"relationships"`
+}
+
+type SPDXCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type SPDXPackage struct {
+	SPDXID           string `json:"SPDXID"`
+	Name             string `json:"name"`
+	VersionInfo      string `json:"versionInfo"`
+	DownloadLocation string `json:"downloadLocation"`
+	LicenseConcluded string `json:"licenseConcluded"`
+	LicenseDeclared  string `json:"licenseDeclared"`
+	CopyrightText    string `json:"copyrightText"`
+}
+
+type SPDXRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// spdxPackageID turns a package@version ref into a valid SPDX element ID,
+// which may only contain letters, digits, ".", and "-".
+func spdxPackageID(ref string) string {
+	var b strings.Builder
+	b.WriteString("SPDXRef-Package-")
+	for _, r := range ref {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
+// NewSPDXDocument builds an SPDX 2.3 document named docName from a set of
+// findings, one package per distinct package@version. It doesn't attempt
+// to record vulnerabilities against packages - SPDX's own vulnerability
+// vocabulary (the OpenVEX-style "security" profile) is out of scope here;
+// use NewCycloneDXBOM or NewSARIFReport when findings themselves need to
+// travel with the report.
+func NewSPDXDocument(docName string, findings []Finding) *SPDXDocument {
+	doc := &SPDXDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              docName,
+		DocumentNamespace: fmt.Sprintf("https://github.com/nmsde/docktor/spdx/%s", docName),
+		CreationInfo: SPDXCreationInfo{
+			Created:  time.Now().UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: docktor"},
+		},
+	}
+
+	seenRefs := make(map[string]bool)
+	usedIDs := make(map[string]bool)
+	for _, f := range findings {
+		ref := fmt.Sprintf("%s@%s", f.PkgName, f.InstalledVersion)
+		if seenRefs[ref] {
+			continue
+		}
+		seenRefs[ref] = true
+
+		// Distinct refs can sanitize to the same ID (e.g. refs differing
+		// only in characters spdxPackageID maps to "-"); disambiguate
+		// against every ID used so far, including previously-disambiguated
+		// ones, so every package keeps a unique SPDXID as SPDX 2.3 requires.
+		base := spdxPackageID(ref)
+		id := base
+		for n := 1; usedIDs[id]; n++ {
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+		usedIDs[id] = true
+
+		doc.Packages = append(doc.Packages, SPDXPackage{
+			SPDXID:           id,
+			Name:             f.PkgName,
+			VersionInfo:      f.InstalledVersion,
+			DownloadLocation: "NOASSERTION",
+			LicenseConcluded: "NOASSERTION",
+			LicenseDeclared:  "NOASSERTION",
+			CopyrightText:    "NOASSERTION",
+		})
+		doc.Relationships = append(doc.Relationships, SPDXRelationship{
+			SPDXElementID:      "SPDXRef-DOCUMENT",
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: id,
+		})
+	}
+
+	return doc
+}
+
+// Marshal renders the document as indented JSON.
+func (d *SPDXDocument) Marshal() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// SeverityMeetsThreshold reports whether severity is at or above the given
+// threshold, using the standard CRITICAL > HIGH > MEDIUM > LOW > UNKNOWN
+// ordering. An empty threshold never matches.
+func SeverityMeetsThreshold(severity, threshold string) bool {
+	if threshold == "" {
+		return false
+	}
+
+	order := map[string]int{
+		"CRITICAL": 4,
+		"HIGH":     3,
+		"MEDIUM":   2,
+		"LOW":      1,
+		"UNKNOWN":  0,
+	}
+
+	return order[severity] >= order[threshold]
+}
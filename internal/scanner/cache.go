@@ -0,0 +1,105 @@
+package scanner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// resultCacheDir is the subdirectory of a ScanOptions.CacheDir holding
+// cached ScanReports, keyed by (image digest, DB version) so a cache
+// layout is easy to inspect or prune by hand.
+const resultCacheDir = "results"
+
+// cachedScanReport reads a previously cached ScanReport for (imageDigest,
+// dbVersion) out of cacheDir, if present. A miss (including cacheDir being
+// unset) is not an error - callers fall back to running trivy.
+func cachedScanReport(cacheDir, imageDigest, dbVersion string) (*ScanReport, bool) {
+	if cacheDir == "" || imageDigest == "" {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(filepath.Join(cacheDir, resultCacheDir, resultCacheKey(imageDigest, dbVersion)+".json"))
+	if err != nil {
+		return nil, false
+	}
+
+	var report ScanReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, false
+	}
+
+	return &report, true
+}
+
+// writeCachedScanReport stores report under cacheDir for (imageDigest,
+// dbVersion). Failures are non-fatal - caching is a best-effort speedup,
+// not a correctness requirement.
+func writeCachedScanReport(cacheDir, imageDigest, dbVersion string, report *ScanReport) {
+	if cacheDir == "" || imageDigest == "" {
+		return
+	}
+
+	dir := filepath.Join(cacheDir, resultCacheDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return
+	}
+
+	_ = os.WriteFile(filepath.Join(dir, resultCacheKey(imageDigest, dbVersion)+".json"), data, 0644)
+}
+
+// resultCacheKey derives a filesystem-safe cache key from an image digest
+// and the trivy vulnerability DB version that scanned it, so a DB update
+// naturally invalidates every image's cached result.
+func resultCacheKey(imageDigest, dbVersion string) string {
+	sum := sha256.Sum256([]byte(imageDigest + "@" + dbVersion))
+	return hex.EncodeToString(sum[:])
+}
+
+// resolveImageDigest returns the local Docker image ID for imageName, for
+// use as a cache key. It only works against images present in the local
+// Docker daemon, so it's a no-op (empty string, nil error) for images that
+// only exist on a remote scanning machine.
+func resolveImageDigest(ctx context.Context, imageName string) (string, error) {
+	out, err := exec.CommandContext(ctx, "docker", "inspect", "--format", "{{.Id}}", imageName).Output()
+	if err != nil {
+		return "", nil
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+var trivyDBVersionRe = regexp.MustCompile(`Vulnerability DB:\s*\n\s*Version:\s*(\d+)`)
+
+// trivyDBVersion returns the version of the vulnerability DB currently
+// cached in cacheDir, by asking trivy directly, so a DB update naturally
+// invalidates stale cached results.
+func trivyDBVersion(ctx context.Context, cacheDir string) (string, error) {
+	args := []string{"--version"}
+	if cacheDir != "" {
+		args = append(args, "--cache-dir", cacheDir)
+	}
+
+	out, err := exec.CommandContext(ctx, "trivy", args...).Output()
+	if err != nil {
+		return "", err
+	}
+
+	m := trivyDBVersionRe.FindStringSubmatch(string(out))
+	if len(m) < 2 {
+		// No DB downloaded yet, or unrecognized --version output.
+		return "", nil
+	}
+
+	return m[1], nil
+}
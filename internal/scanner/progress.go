@@ -0,0 +1,71 @@
+package scanner
+
+import (
+	"os"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// ProgressReporter reports a TrivyScanner's progress through a scan's
+// phases (downloading the vulnerability DB, pulling the image, analyzing
+// layers, scanning for vulnerabilities), so a Runner can drive a terminal
+// progress bar, or report nothing at all in CI.
+type ProgressReporter interface {
+	// Phase announces entry into a new named phase. total is the number
+	// of steps in that phase if known, or 0 if it isn't (in which case
+	// implementations should render an indeterminate/spinner bar).
+	Phase(name string, total int)
+	// Step advances the current phase by one unit.
+	Step()
+	// Done marks the scan as finished, successfully or not, so any bar
+	// gets a chance to render its final state instead of being left
+	// mid-progress.
+	Done()
+}
+
+// TerminalReporter renders Phase transitions as a progress bar with an
+// ETA and transfer rate, for interactive use.
+type TerminalReporter struct {
+	bar *progressbar.ProgressBar
+}
+
+// NewTerminalReporter creates a ProgressReporter that renders a bar to
+// stderr.
+func NewTerminalReporter() *TerminalReporter {
+	return &TerminalReporter{}
+}
+
+func (r *TerminalReporter) Phase(name string, total int) {
+	if total <= 0 {
+		total = -1 // progressbar renders an indeterminate spinner for a negative max.
+	}
+	r.bar = progressbar.NewOptions(total,
+		progressbar.OptionSetDescription(name),
+		progressbar.OptionSetWriter(os.Stderr),
+		progressbar.OptionShowCount(),
+		progressbar.OptionShowIts(),
+		progressbar.OptionThrottle(100*time.Millisecond),
+		progressbar.OptionOnCompletion(func() { _, _ = os.Stderr.Write([]byte("\n")) }),
+	)
+}
+
+func (r *TerminalReporter) Step() {
+	if r.bar != nil {
+		_ = r.bar.Add(1)
+	}
+}
+
+func (r *TerminalReporter) Done() {
+	if r.bar != nil {
+		_ = r.bar.Finish()
+	}
+}
+
+// SilentReporter discards all progress, for CI or any other
+// non-interactive use (the --no-progress and --silent CLI flags).
+type SilentReporter struct{}
+
+func (SilentReporter) Phase(name string, total int) {}
+func (SilentReporter) Step()                        {}
+func (SilentReporter) Done()                        {}
@@ -1,22 +1,35 @@
 package scanner
 
 import (
-	"bytes"
+	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
+
+	"github.com/nmsde/docktor/internal/report"
 )
 
-type TrivyScanner struct {
-	client *http.Client
+// Runner executes `trivy image` against some environment - a local binary
+// or a remote machine - and returns its raw `--format json` output,
+// reporting its progress through progress as it goes.
+// TrivyScanner decodes whatever the Runner gives it, so the same decoding
+// and reporting logic works no matter where the scan actually ran.
+type Runner interface {
+	Run(ctx context.Context, imageName string, opts ScanOptions, progress ProgressReporter) ([]byte, error)
 }
 
-type VulnerabilityReport struct {
-	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
-	Summary         Summary         `json:"summary"`
+// TrivyScanner runs `trivy image` via a Runner and decodes the result into
+// a ScanReport.
+type TrivyScanner struct {
+	runner   Runner
+	progress ProgressReporter
 }
 
 type Vulnerability struct {
@@ -47,10 +60,86 @@ const (
 	SeverityUnknown  Severity = "UNKNOWN"
 )
 
+// Misconfiguration is a single IaC/config finding, produced by Trivy's
+// "config" check.
+type Misconfiguration struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Severity    Severity `json:"severity"`
+	Resolution  string   `json:"resolution,omitempty"`
+	File        string   `json:"file"`
+	Line        int      `json:"line,omitempty"`
+}
+
+// Secret is a single credential/token finding, produced by Trivy's
+// "secret" check.
+type Secret struct {
+	RuleID   string   `json:"rule_id"`
+	Category string   `json:"category"`
+	Title    string   `json:"title"`
+	Severity Severity `json:"severity"`
+	File     string   `json:"file"`
+	Line     int      `json:"line,omitempty"`
+}
+
+// License is a single license finding, produced by Trivy's "license"
+// check.
+type License struct {
+	PkgName  string   `json:"package"`
+	Name     string   `json:"name"`
+	Severity Severity `json:"severity"`
+}
+
+// Check identifies one of Trivy's pluggable "security checks".
+type Check string
+
+const (
+	CheckVulnerability    Check = "vuln"
+	CheckMisconfiguration Check = "config"
+	CheckSecret           Check = "secret"
+	CheckLicense          Check = "license"
+)
+
+// ScanOptions configures which checks ScanImage runs and how.
+type ScanOptions struct {
+	// Checks selects which security checks to run. Defaults to
+	// []Check{CheckVulnerability} if empty.
+	Checks []Check
+	// VulnTypes restricts the vuln check to "os", "library", or both.
+	// Defaults to both if empty.
+	VulnTypes []string
+	// IgnoreUnfixed drops vulnerabilities with no available fix.
+	IgnoreUnfixed bool
+	// SkipDBUpdate skips checking for a newer vulnerability DB, reusing
+	// whatever is already in CacheDir (trivy's --skip-db-update).
+	SkipDBUpdate bool
+	// OfflineScan additionally avoids any network calls during the scan
+	// itself, e.g. for language-specific advisory lookups (trivy's
+	// --offline-scan). Implies SkipDBUpdate.
+	OfflineScan bool
+	// CacheDir points trivy at a specific vulnerability DB / results
+	// cache directory (trivy's --cache-dir), instead of its own default.
+	// Also used to key TrivyScanner's own result cache, when set.
+	CacheDir string
+}
+
+// checksOrDefault returns opts.Checks, defaulting to a vuln-only scan when
+// none were specified.
+func (opts ScanOptions) checksOrDefault() []Check {
+	if len(opts.Checks) == 0 {
+		return []Check{CheckVulnerability}
+	}
+	return opts.Checks
+}
+
 type ScanReport struct {
-	ImageName       string          `json:"image_name"`
-	ScanTime        string          `json:"scan_time"`
-	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+	ImageName         string             `json:"image_name"`
+	ScanTime          string             `json:"scan_time"`
+	Vulnerabilities   []Vulnerability    `json:"vulnerabilities"`
+	Misconfigurations []Misconfiguration `json:"misconfigurations,omitempty"`
+	Secrets           []Secret           `json:"secrets,omitempty"`
+	Licenses          []License          `json:"licenses,omitempty"`
 }
 
 type ScanStatus struct {
@@ -60,116 +149,302 @@ type ScanStatus struct {
 	ReportURL string `json:"report_url,omitempty"`
 }
 
-func NewTrivyScanner() *TrivyScanner {
-	return &TrivyScanner{
-		client: &http.Client{},
+// Args renders opts into the `--scanners`, `--vuln-type`,
+// `--ignore-unfixed`, and `--skip-db-update` flags trivy expects, for a
+// Runner building its own trivy invocation.
+func (opts ScanOptions) Args() []string {
+	checks := make([]string, 0, len(opts.checksOrDefault()))
+	for _, c := range opts.checksOrDefault() {
+		checks = append(checks, string(c))
+	}
+	args := []string{"--scanners", strings.Join(checks, ",")}
+
+	if len(opts.VulnTypes) > 0 {
+		args = append(args, "--vuln-type", strings.Join(opts.VulnTypes, ","))
+	}
+	if opts.IgnoreUnfixed {
+		args = append(args, "--ignore-unfixed")
+	}
+	if opts.SkipDBUpdate || opts.OfflineScan {
+		args = append(args, "--skip-db-update")
+	}
+	if opts.OfflineScan {
+		args = append(args, "--offline-scan")
+	}
+	if opts.CacheDir != "" {
+		args = append(args, "--cache-dir", opts.CacheDir)
+	}
+
+	return args
+}
+
+// NewTrivyScanner creates a TrivyScanner that runs scans through runner,
+// e.g. a BinaryRunner for a local trivy install or a RemoteRunner for a
+// remote machine. progress is notified of scan phases as they happen; pass
+// SilentReporter{} to report nothing (e.g. for CI or --silent/--no-progress).
+func NewTrivyScanner(runner Runner, progress ProgressReporter) *TrivyScanner {
+	if progress == nil {
+		progress = SilentReporter{}
 	}
+	return &TrivyScanner{runner: runner, progress: progress}
 }
 
-func (s *TrivyScanner) ScanImage(machineID, imageName string) (*ScanReport, error) {
-	// Run Trivy scan with optimizations
-	scanCmd := fmt.Sprintf(
-		"trivy image --format json --no-progress --scanners vuln %s > /tmp/trivy-scan.json 2>&1 & echo $!",
-		imageName,
-	)
-	
-	req, err := http.NewRequest("POST", fmt.Sprintf("https://api.fly.io/v1/machines/%s/exec", machineID), bytes.NewBufferString(scanCmd))
+// ScanImage runs `trivy image` via the scanner's Runner according to opts
+// and returns a ScanReport aggregating findings across every check opts
+// enables (vulnerabilities, misconfigurations, secrets, licenses). ctx
+// cancellation (e.g. Ctrl-C) aborts the scan instead of leaving it running.
+func (s *TrivyScanner) ScanImage(ctx context.Context, imageName string, opts ScanOptions) (*ScanReport, error) {
+	imageDigest, dbVersion := "", ""
+	if opts.CacheDir != "" {
+		imageDigest, _ = resolveImageDigest(ctx, imageName)
+		dbVersion, _ = trivyDBVersion(ctx, opts.CacheDir)
+		if cached, ok := cachedScanReport(opts.CacheDir, imageDigest, dbVersion); ok {
+			return cached, nil
+		}
+	}
+
+	raw, err := s.runner.Run(ctx, imageName, opts, s.progress)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create scan request: %w", err)
+		return nil, err
+	}
+
+	var trivyReport trivyOutput
+	if err := json.Unmarshal(raw, &trivyReport); err != nil {
+		return nil, fmt.Errorf("failed to decode scan results: %w", err)
+	}
+
+	scanReport := trivyReport.toScanReport(imageName)
+	writeCachedScanReport(opts.CacheDir, imageDigest, dbVersion, scanReport)
+
+	return scanReport, nil
+}
+
+// WarmCache downloads the trivy vulnerability DB into cacheDir if it's
+// missing or stale, so a later scan with ScanOptions{CacheDir: cacheDir,
+// OfflineScan: true} can run without any network access. It's safe to
+// call before every scan - trivy no-ops when its cached DB is still
+// fresh.
+func (s *TrivyScanner) WarmCache(ctx context.Context, cacheDir string) error {
+	cmd := exec.CommandContext(ctx, "trivy", "image", "--download-db-only", "--cache-dir", cacheDir)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to warm trivy vulnerability DB cache: %w: %s", err, stderr.String())
 	}
 
-	resp, err := s.client.Do(req)
+	return nil
+}
+
+// minTrivyVersion is the oldest trivy release docktor's flag set
+// (--scanners, --vuln-type, --ignore-unfixed, --offline-scan) is known to
+// work against.
+const minTrivyVersion = "0.45.0"
+
+var trivyVersionRe = regexp.MustCompile(`Version:\s*v?(\d+\.\d+\.\d+)`)
+
+// checkTrivyVersion verifies the trivy binary is on PATH and at least
+// minTrivyVersion, so a missing or too-old binary fails fast with a clear
+// error instead of a confusing flag-parsing failure mid-scan.
+func checkTrivyVersion(ctx context.Context) error {
+	out, err := exec.CommandContext(ctx, "trivy", "--version").Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to start Trivy scan: %w", err)
+		return fmt.Errorf("trivy binary not found on PATH (required for local scanning): %w", err)
 	}
-	defer resp.Body.Close()
 
-	// Get the process ID
-	var pid int
-	if err := json.NewDecoder(resp.Body).Decode(&pid); err != nil {
-		return nil, fmt.Errorf("failed to get scan process ID: %w", err)
+	m := trivyVersionRe.FindStringSubmatch(string(out))
+	if len(m) < 2 {
+		// Unrecognized --version output; don't block the scan on a parse miss.
+		return nil
 	}
+	if compareVersions(m[1], minTrivyVersion) < 0 {
+		return fmt.Errorf("trivy %s is older than the minimum supported version %s", m[1], minTrivyVersion)
+	}
+
+	return nil
+}
 
-	// Poll for scan completion with timeout
-	fmt.Println("Starting vulnerability scan...")
-	timeout := 10 * time.Minute // Maximum scan time
-	startTime := time.Now()
-	lastProgress := time.Now()
+// compareVersions compares two "major.minor.patch" version strings,
+// returning <0, 0, or >0 as a < b, a == b, or a > b.
+func compareVersions(a, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
 
-	for {
-		// Check if process is still running
-		checkCmd := fmt.Sprintf("ps -p %d > /dev/null && echo 'running' || echo 'completed'", pid)
-		req, err = http.NewRequest("POST", fmt.Sprintf("https://api.fly.io/v1/machines/%s/exec", machineID), bytes.NewBufferString(checkCmd))
-		if err != nil {
-			return nil, fmt.Errorf("failed to check scan status: %w", err)
+	for i := 0; i < 3; i++ {
+		var an, bn int
+		if i < len(aParts) {
+			an, _ = strconv.Atoi(aParts[i])
 		}
-
-		resp, err = s.client.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("failed to check scan status: %w", err)
+		if i < len(bParts) {
+			bn, _ = strconv.Atoi(bParts[i])
 		}
-
-		var status string
-		if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
-			resp.Body.Close()
-			return nil, fmt.Errorf("failed to decode status: %w", err)
+		if an != bn {
+			return an - bn
 		}
-		resp.Body.Close()
+	}
 
-		if status == "completed" {
-			break
-		}
+	return 0
+}
 
-		// Check for timeout
-		if time.Since(startTime) > timeout {
-			// Kill the process if it's taking too long
-			killCmd := fmt.Sprintf("kill -9 %d", pid)
-			req, _ = http.NewRequest("POST", fmt.Sprintf("https://api.fly.io/v1/machines/%s/exec", machineID), bytes.NewBufferString(killCmd))
-			s.client.Do(req)
-			return nil, fmt.Errorf("scan timed out after %v", timeout)
-		}
+// BinaryRunner runs `trivy image` via the local trivy CLI, for the local
+// builder backend and offline/CI use.
+type BinaryRunner struct{}
 
-		// Show progress every 5 seconds
-		if time.Since(lastProgress) >= 5*time.Second {
-			fmt.Print(".")
-			lastProgress = time.Now()
-		}
+// NewBinaryRunner creates a Runner that shells out to a trivy binary on
+// PATH.
+func NewBinaryRunner() *BinaryRunner {
+	return &BinaryRunner{}
+}
 
-		time.Sleep(1 * time.Second)
+// Run probes the local trivy binary's version, then runs it with its JSON
+// output directed to a temporary file (read back directly, rather than
+// parsed from stdout) so progress output on stderr can't corrupt the
+// result. trivy's own stderr log lines are scanned as they arrive and
+// translated into progress's phases; ctx cancellation kills the trivy
+// process, and the temp file is always removed.
+func (r *BinaryRunner) Run(ctx context.Context, imageName string, opts ScanOptions, progress ProgressReporter) ([]byte, error) {
+	if err := checkTrivyVersion(ctx); err != nil {
+		return nil, err
 	}
-	fmt.Println("\nScan completed!")
 
-	// Get the scan results
-	readCmd := "cat /tmp/trivy-scan.json"
-	req, err = http.NewRequest("POST", fmt.Sprintf("https://api.fly.io/v1/machines/%s/exec", machineID), bytes.NewBufferString(readCmd))
+	tmp, err := os.CreateTemp("", "trivy-*.json")
 	if err != nil {
-		return nil, fmt.Errorf("failed to read scan results: %w", err)
+		return nil, fmt.Errorf("failed to create temp file for scan output: %w", err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	args := append([]string{"image", "--format", "json", "--output", tmp.Name()}, opts.Args()...)
+	if _, silent := progress.(SilentReporter); silent {
+		args = append(args, "--quiet")
 	}
+	args = append(args, imageName)
 
-	resp, err = s.client.Do(req)
+	cmd := exec.CommandContext(ctx, "trivy", args...)
+	stderr, err := cmd.StderrPipe()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read scan results: %w", err)
+		return nil, fmt.Errorf("failed to attach to trivy output: %w", err)
 	}
-	defer resp.Body.Close()
 
-	var trivyReport VulnerabilityReport
-	if err := json.NewDecoder(resp.Body).Decode(&trivyReport); err != nil {
-		return nil, fmt.Errorf("failed to decode scan results: %w", err)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start trivy: %w", err)
 	}
+	progress.Phase("scanning image", 0)
+	defer progress.Done()
+
+	var stderrBuf strings.Builder
+	logDone := make(chan struct{})
+	go func() {
+		defer close(logDone)
+		lines := bufio.NewScanner(stderr)
+		for lines.Scan() {
+			line := lines.Text()
+			stderrBuf.WriteString(line)
+			stderrBuf.WriteByte('\n')
+			reportTrivyPhase(progress, line)
+		}
+	}()
+	<-logDone
 
-	// Clean up temporary files
-	cleanupCmd := "rm -f /tmp/trivy-scan.json"
-	req, _ = http.NewRequest("POST", fmt.Sprintf("https://api.fly.io/v1/machines/%s/exec", machineID), bytes.NewBufferString(cleanupCmd))
-	s.client.Do(req)
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("trivy scan failed: %w: %s", err, stderrBuf.String())
+	}
 
-	// Convert Trivy report to our ScanReport format
+	result, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scan output: %w", err)
+	}
+
+	return result, nil
+}
+
+// reportTrivyPhase maps a line of trivy's own log output to a
+// ProgressReporter phase transition, based on substrings trivy is known to
+// log as it moves through a scan.
+func reportTrivyPhase(progress ProgressReporter, line string) {
+	switch {
+	case strings.Contains(line, "Downloading DB") || strings.Contains(line, "Need to update DB"):
+		progress.Phase("downloading vulnerability database", 0)
+	case strings.Contains(line, "Pulling"):
+		progress.Phase("pulling image", 0)
+	case strings.Contains(line, "Detecting") || strings.Contains(line, "language-specific"):
+		progress.Phase("analyzing layers", 0)
+	case strings.Contains(line, "vulnerabilit"):
+		progress.Phase("scanning vulnerabilities", 0)
+	}
+}
+
+// trivyOutput mirrors the subset of `trivy image --format json` we care
+// about: one Result entry per scanned target (the image's OS packages,
+// each application lockfile, filesystem config, ...), each of which may
+// carry any combination of the four check types depending on --scanners.
+type trivyOutput struct {
+	Results []struct {
+		Vulnerabilities   []Vulnerability `json:"Vulnerabilities"`
+		Misconfigurations []struct {
+			ID          string `json:"ID"`
+			Title       string `json:"Title"`
+			Description string `json:"Description"`
+			Severity    string `json:"Severity"`
+			Resolution  string `json:"Resolution"`
+		} `json:"Misconfigurations"`
+		Secrets []struct {
+			RuleID    string `json:"RuleID"`
+			Category  string `json:"Category"`
+			Title     string `json:"Title"`
+			Severity  string `json:"Severity"`
+			StartLine int    `json:"StartLine"`
+		} `json:"Secrets"`
+		Licenses []struct {
+			PkgName  string `json:"PkgName"`
+			Name     string `json:"Name"`
+			Severity string `json:"Severity"`
+		} `json:"Licenses"`
+		Target string `json:"Target"`
+	} `json:"Results"`
+}
+
+// toScanReport flattens every target's findings into a single ScanReport.
+func (o trivyOutput) toScanReport(imageName string) *ScanReport {
 	report := &ScanReport{
-		ImageName:       imageName,
-		ScanTime:        time.Now().Format(time.RFC3339),
-		Vulnerabilities: trivyReport.Vulnerabilities,
+		ImageName: imageName,
+		ScanTime:  time.Now().Format(time.RFC3339),
 	}
 
-	return report, nil
+	for _, result := range o.Results {
+		report.Vulnerabilities = append(report.Vulnerabilities, result.Vulnerabilities...)
+
+		for _, m := range result.Misconfigurations {
+			report.Misconfigurations = append(report.Misconfigurations, Misconfiguration{
+				ID:          m.ID,
+				Title:       m.Title,
+				Description: m.Description,
+				Severity:    Severity(m.Severity),
+				Resolution:  m.Resolution,
+				File:        result.Target,
+			})
+		}
+
+		for _, s := range result.Secrets {
+			report.Secrets = append(report.Secrets, Secret{
+				RuleID:   s.RuleID,
+				Category: s.Category,
+				Title:    s.Title,
+				Severity: Severity(s.Severity),
+				File:     result.Target,
+				Line:     s.StartLine,
+			})
+		}
+
+		for _, l := range result.Licenses {
+			report.Licenses = append(report.Licenses, License{
+				PkgName:  l.PkgName,
+				Name:     l.Name,
+				Severity: Severity(l.Severity),
+			})
+		}
+	}
+
+	return report
 }
 
 func (r *ScanReport) FilterBySeverity(minSeverity Severity) *ScanReport {
@@ -202,17 +477,37 @@ func (r *ScanReport) GenerateTable() string {
 	var sb strings.Builder
 	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
 
-	fmt.Fprintln(w, "SEVERITY\tPACKAGE\tVERSION\tFIXED IN\tTITLE")
-	fmt.Fprintln(w, "--------\t-------\t-------\t--------\t-----")
+	if len(r.Vulnerabilities) > 0 {
+		fmt.Fprintln(w, "VULNERABILITY\tSEVERITY\tPACKAGE\tVERSION\tFIXED IN\tTITLE")
+		fmt.Fprintln(w, "-------------\t--------\t-------\t-------\t--------\t-----")
+		for _, vuln := range r.Vulnerabilities {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				vuln.ID, vuln.Severity, vuln.Package, vuln.Version, vuln.FixedIn, vuln.Title)
+		}
+	}
+
+	if len(r.Misconfigurations) > 0 {
+		fmt.Fprintln(w, "\nMISCONFIGURATION\tSEVERITY\tFILE\tTITLE")
+		fmt.Fprintln(w, "----------------\t--------\t----\t-----")
+		for _, m := range r.Misconfigurations {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", m.ID, m.Severity, m.File, m.Title)
+		}
+	}
 
-	for _, vuln := range r.Vulnerabilities {
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
-			vuln.Severity,
-			vuln.Package,
-			vuln.Version,
-			vuln.FixedIn,
-			vuln.Title,
-		)
+	if len(r.Secrets) > 0 {
+		fmt.Fprintln(w, "\nSECRET\tSEVERITY\tFILE\tLINE\tTITLE")
+		fmt.Fprintln(w, "------\t--------\t----\t----\t-----")
+		for _, s := range r.Secrets {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", s.RuleID, s.Severity, s.File, s.Line, s.Title)
+		}
+	}
+
+	if len(r.Licenses) > 0 {
+		fmt.Fprintln(w, "\nLICENSE\tSEVERITY\tPACKAGE")
+		fmt.Fprintln(w, "-------\t--------\t-------")
+		for _, l := range r.Licenses {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", l.Name, l.Severity, l.PkgName)
+		}
 	}
 
 	w.Flush()
@@ -242,6 +537,7 @@ func (r *ScanReport) GenerateHTML() string {
     <h1>Docktor Scan Report</h1>
     <p>Image: ` + r.ImageName + `</p>
     <p>Scan Time: ` + r.ScanTime + `</p>
+    <h2>Vulnerabilities</h2>
     <table>
         <tr>
             <th>Severity</th>
@@ -272,9 +568,52 @@ func (r *ScanReport) GenerateHTML() string {
 			vuln.Description,
 		))
 	}
+	sb.WriteString(`
+    </table>`)
+
+	if len(r.Misconfigurations) > 0 {
+		sb.WriteString(`
+    <h2>Misconfigurations</h2>
+    <table>
+        <tr><th>Severity</th><th>File</th><th>Title</th><th>Resolution</th></tr>`)
+		for _, m := range r.Misconfigurations {
+			sb.WriteString(fmt.Sprintf(`
+        <tr class="%s"><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>`,
+				strings.ToLower(string(m.Severity)), m.Severity, m.File, m.Title, m.Resolution))
+		}
+		sb.WriteString(`
+    </table>`)
+	}
+
+	if len(r.Secrets) > 0 {
+		sb.WriteString(`
+    <h2>Secrets</h2>
+    <table>
+        <tr><th>Severity</th><th>File</th><th>Line</th><th>Title</th></tr>`)
+		for _, s := range r.Secrets {
+			sb.WriteString(fmt.Sprintf(`
+        <tr class="%s"><td>%s</td><td>%s</td><td>%d</td><td>%s</td></tr>`,
+				strings.ToLower(string(s.Severity)), s.Severity, s.File, s.Line, s.Title))
+		}
+		sb.WriteString(`
+    </table>`)
+	}
+
+	if len(r.Licenses) > 0 {
+		sb.WriteString(`
+    <h2>Licenses</h2>
+    <table>
+        <tr><th>Severity</th><th>Package</th><th>License</th></tr>`)
+		for _, l := range r.Licenses {
+			sb.WriteString(fmt.Sprintf(`
+        <tr class="%s"><td>%s</td><td>%s</td><td>%s</td></tr>`,
+				strings.ToLower(string(l.Severity)), l.Severity, l.PkgName, l.Name))
+		}
+		sb.WriteString(`
+    </table>`)
+	}
 
 	sb.WriteString(`
-    </table>
 </body>
 </html>`)
 
@@ -282,16 +621,73 @@ func (r *ScanReport) GenerateHTML() string {
 }
 
 func (r *ScanReport) PrintSummary() {
-	severityCount := make(map[Severity]int)
-	for _, vuln := range r.Vulnerabilities {
-		severityCount[vuln.Severity]++
+	fmt.Println("\nScan Summary:")
+
+	if len(r.Vulnerabilities) > 0 {
+		severityCount := make(map[Severity]int)
+		for _, vuln := range r.Vulnerabilities {
+			severityCount[vuln.Severity]++
+		}
+		fmt.Printf("Vulnerabilities: %d (critical: %d, high: %d, medium: %d, low: %d, unknown: %d)\n",
+			len(r.Vulnerabilities),
+			severityCount[SeverityCritical],
+			severityCount[SeverityHigh],
+			severityCount[SeverityMedium],
+			severityCount[SeverityLow],
+			severityCount[SeverityUnknown],
+		)
 	}
 
-	fmt.Println("\nScan Summary:")
-	fmt.Printf("Total vulnerabilities: %d\n", len(r.Vulnerabilities))
-	fmt.Printf("Critical: %d\n", severityCount[SeverityCritical])
-	fmt.Printf("High: %d\n", severityCount[SeverityHigh])
-	fmt.Printf("Medium: %d\n", severityCount[SeverityMedium])
-	fmt.Printf("Low: %d\n", severityCount[SeverityLow])
-	fmt.Printf("Unknown: %d\n", severityCount[SeverityUnknown])
+	if len(r.Misconfigurations) > 0 {
+		fmt.Printf("Misconfigurations: %d\n", len(r.Misconfigurations))
+	}
+	if len(r.Secrets) > 0 {
+		fmt.Printf("Secrets: %d\n", len(r.Secrets))
+	}
+	if len(r.Licenses) > 0 {
+		fmt.Printf("Licenses flagged: %d\n", len(r.Licenses))
+	}
+}
+
+// toFindings converts r's vulnerabilities into the format-agnostic shape
+// the report package builds SARIF/CycloneDX documents from.
+func (r *ScanReport) toFindings() []report.Finding {
+	findings := make([]report.Finding, 0, len(r.Vulnerabilities))
+	for _, v := range r.Vulnerabilities {
+		findings = append(findings, report.Finding{
+			ID:               v.ID,
+			PkgName:          v.Package,
+			InstalledVersion: v.Version,
+			FixedVersion:     v.FixedIn,
+			Severity:         string(v.Severity),
+			Title:            v.Title,
+			Description:      v.Description,
+			ImageName:        r.ImageName,
+		})
+	}
+	return findings
+}
+
+// GenerateSARIF renders r as a SARIF 2.1.0 log, with each vulnerability as
+// a result keyed to a rule for its CVE ID, suitable for upload to GitHub
+// code scanning.
+func (r *ScanReport) GenerateSARIF() ([]byte, error) {
+	sarif := report.NewSARIFReport("trivy", r.toFindings())
+	data, err := sarif.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal SARIF report: %w", err)
+	}
+	return data, nil
+}
+
+// GenerateCycloneDX renders r as a CycloneDX 1.5 document, with one
+// component per distinct package@version and its vulnerabilities linked
+// by bom-ref.
+func (r *ScanReport) GenerateCycloneDX() ([]byte, error) {
+	bom := report.NewCycloneDXBOM(r.toFindings())
+	data, err := bom.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal CycloneDX document: %w", err)
+	}
+	return data, nil
 } 
\ No newline at end of file